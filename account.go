@@ -0,0 +1,62 @@
+package documentdb
+
+// DatabaseAccount holds the metadata of the database account, see ReadDatabaseAccount
+type DatabaseAccount struct {
+	Resource
+	// WritableLocations lists the regions accepting writes, the first one is the write region
+	// unless EnableMultipleWriteLocations is set
+	WritableLocations []AccountLocation `json:"writableLocations,omitempty"`
+	// ReadableLocations lists the regions serving reads, in failover priority order
+	ReadableLocations            []AccountLocation `json:"readableLocations,omitempty"`
+	EnableMultipleWriteLocations bool              `json:"enableMultipleWriteLocations,omitempty"`
+	ConsistencyPolicy            ConsistencyPolicy `json:"userConsistencyPolicy"`
+}
+
+// AccountLocation is a region of the database account
+type AccountLocation struct {
+	Name     string `json:"name"`
+	Endpoint string `json:"databaseAccountEndpoint"`
+}
+
+// ConsistencyPolicy is the default consistency of the account, requests can relax it with ConsistencyLevel
+type ConsistencyPolicy struct {
+	// DefaultConsistencyLevel is one of "Strong", "BoundedStaleness", "Session", "ConsistentPrefix" or "Eventual"
+	DefaultConsistencyLevel Consistency `json:"defaultConsistencyLevel"`
+	// MaxStalenessPrefix and MaxStalenessIntervalInSeconds bound the lag of BoundedStaleness reads
+	MaxStalenessPrefix            int `json:"maxStalenessPrefix,omitempty"`
+	MaxStalenessIntervalInSeconds int `json:"maxIntervalInSeconds,omitempty"`
+}
+
+// FailoverPolicy describes how the account behaves on a regional outage, as far as the account metadata tells
+type FailoverPolicy struct {
+	// WriteRegion is the region accepting writes, empty with multiple write locations
+	WriteRegion string
+	// FailoverPriorities lists the regions by failover priority, the write region first
+	FailoverPriorities []string
+	// MultipleWriteLocations is set when every region accepts writes, so a regional outage
+	// doesn't require a failover
+	MultipleWriteLocations bool
+}
+
+// FailoverPolicy returns the failover policy of the account.
+// Whether automatic failover is enabled isn't part of the account metadata: it's only
+// exposed by the management API(Azure Resource Manager), and can't be read with the account key.
+func (a *DatabaseAccount) FailoverPolicy() FailoverPolicy {
+	policy := FailoverPolicy{MultipleWriteLocations: a.EnableMultipleWriteLocations}
+	if !a.EnableMultipleWriteLocations && len(a.WritableLocations) > 0 {
+		policy.WriteRegion = a.WritableLocations[0].Name
+	}
+	for _, location := range a.ReadableLocations {
+		policy.FailoverPriorities = append(policy.FailoverPriorities, location.Name)
+	}
+	return policy
+}
+
+// Read the database account metadata: its regions and default consistency
+func (c *DocumentDB) ReadDatabaseAccount(opts ...CallOption) (account *DatabaseAccount, err error) {
+	_, err = c.client.Read("", &account, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return
+}
@@ -0,0 +1,39 @@
+package documentdb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadDatabaseAccount(t *testing.T) {
+	assert := assert.New(t)
+	s := ServerFactory(`{
+		"id": "account",
+		"writableLocations": [{"name": "West US", "databaseAccountEndpoint": "https://account-westus.documents.azure.com:443/"}],
+		"readableLocations": [
+			{"name": "West US", "databaseAccountEndpoint": "https://account-westus.documents.azure.com:443/"},
+			{"name": "East US", "databaseAccountEndpoint": "https://account-eastus.documents.azure.com:443/"}
+		],
+		"enableMultipleWriteLocations": false,
+		"userConsistencyPolicy": {"defaultConsistencyLevel": "BoundedStaleness", "maxStalenessPrefix": 100, "maxIntervalInSeconds": 5}
+	}`)
+	defer s.Close()
+	c := New(s.URL, NewConfig(&Key{Key: "YXJpZWwNCg=="}))
+
+	account, err := c.ReadDatabaseAccount()
+	assert.NoError(err)
+	s.AssertHeaders(t, HeaderXDate, HeaderAuth, HeaderVersion)
+	assert.Equal("account", account.Id)
+	assert.Equal(ConsistencyPolicy{DefaultConsistencyLevel: "BoundedStaleness", MaxStalenessPrefix: 100, MaxStalenessIntervalInSeconds: 5}, account.ConsistencyPolicy)
+	assert.Equal(FailoverPolicy{WriteRegion: "West US", FailoverPriorities: []string{"West US", "East US"}}, account.FailoverPolicy())
+
+	account.EnableMultipleWriteLocations = true
+	assert.Equal(FailoverPolicy{FailoverPriorities: []string{"West US", "East US"}, MultipleWriteLocations: true}, account.FailoverPolicy())
+}
+
+func TestParseDatabaseAccount(t *testing.T) {
+	rId, rType := parse("")
+	assert.Empty(t, rId)
+	assert.Empty(t, rType)
+}
@@ -5,12 +5,14 @@ import (
 	"crypto/sha256"
 	"encoding/base64"
 	"errors"
+	"sync"
 )
 
 type Key struct {
 	Key  string
 	salt []byte
 	err  error
+	once sync.Once
 }
 
 func NewKey(key string) *Key {
@@ -18,14 +20,14 @@ func NewKey(key string) *Key {
 }
 
 func (k *Key) Salt() ([]byte, error) {
-	if len(k.salt) == 0 && k.err == nil {
+	k.once.Do(func() {
 		k.salt, k.err = base64.StdEncoding.DecodeString(k.Key)
 		if k.err != nil {
 			if _, ok := k.err.(base64.CorruptInputError); ok {
 				k.err = errors.New("base64 input is corrupt, check CosmosDB key.")
 			}
 		}
-	}
+	})
 	return k.salt, k.err
 }
 
@@ -0,0 +1,139 @@
+package documentdb
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+const (
+	// BatchVersion is the api version required by transactional batch requests
+	BatchVersion = "2018-12-31"
+
+	// MaxBatchOperations is the maximum number of operations in a transactional batch
+	MaxBatchOperations = 100
+
+	// MaxBatchSize is the maximum size in bytes of a transactional batch request
+	MaxBatchSize = 2 << 20
+)
+
+// Batch operation types
+const (
+	BatchCreate  = "Create"
+	BatchUpsert  = "Upsert"
+	BatchReplace = "Replace"
+	BatchDelete  = "Delete"
+	BatchRead    = "Read"
+)
+
+// BatchOperation is a single operation of a transactional batch
+type BatchOperation struct {
+	// OperationType is one of BatchCreate, BatchUpsert, BatchReplace, BatchDelete or BatchRead
+	OperationType string `json:"operationType"`
+	// Id of the document, required by Replace, Delete and Read
+	Id string `json:"id,omitempty"`
+	// ResourceBody is the document written by Create, Upsert and Replace
+	ResourceBody interface{} `json:"resourceBody,omitempty"`
+	// IfMatch makes the operation conditional on the document etag
+	IfMatch string `json:"ifMatch,omitempty"`
+}
+
+// BatchOperationResult is the outcome of a single batch operation
+type BatchOperationResult struct {
+	StatusCode    int             `json:"statusCode"`
+	SubStatusCode int             `json:"subStatusCode,omitempty"`
+	RequestCharge float64         `json:"requestCharge,omitempty"`
+	Etag          string          `json:"eTag,omitempty"`
+	ResourceBody  json.RawMessage `json:"resourceBody,omitempty"`
+}
+
+// BatchError is returned when a transactional batch is rolled back.
+// The operations that didn't cause the failure are reported with 424(Failed Dependency).
+type BatchError struct {
+	// Index of the operation that caused the rollback, -1 if the server didn't report it
+	Index int
+	// Results of all operations, in the order of the batch. Nil if the server didn't report them.
+	Results []BatchOperationResult
+	*RequestError
+}
+
+// Unwrap returns the error of the whole batch request
+func (e *BatchError) Unwrap() error {
+	return e.RequestError
+}
+
+// Implement Error function
+func (e *BatchError) Error() string {
+	if e.Index < 0 {
+		return fmt.Sprintf("batch failed: %v", e.RequestError)
+	}
+	return fmt.Sprintf("batch operation %d failed with status %d: %v", e.Index, e.Results[e.Index].StatusCode, e.RequestError)
+}
+
+// ExecuteBatch executes the operations as a single transaction on the given logical partition:
+// either all of them succeed, or none is applied. On failure the returned error is a *BatchError
+// pointing to the offending operation.
+func (c *DocumentDB) ExecuteBatch(coll string, partitionKey interface{}, ops []BatchOperation, opts ...CallOption) (results []BatchOperationResult, r *Response, err error) {
+	if ops, err = encodeBatchOperations(c.config, ops); err != nil {
+		return nil, nil, err
+	}
+	opts = append(opts, PartitionKey(partitionKey), batchHeaders)
+	r, err = c.client.Execute(coll+"docs/", ops, &results, opts...)
+	if reqErr, ok := err.(*RequestError); ok {
+		return nil, nil, newBatchError(reqErr)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return results, r, nil
+}
+
+// Encode the documents written by the operations like Create, Upsert and Replace do, see Client.encode
+func encodeBatchOperations(config *Config, ops []BatchOperation) ([]BatchOperation, error) {
+	if config == nil {
+		return ops, nil
+	}
+	encoded := make([]BatchOperation, len(ops))
+	for i, op := range ops {
+		encoded[i] = op
+		if _, ok := op.ResourceBody.(encodedDocument); ok || op.ResourceBody == nil {
+			continue
+		}
+		data, err := encodeDocument(config, op.ResourceBody)
+		if err != nil {
+			return nil, err
+		}
+		encoded[i].ResourceBody = json.RawMessage(data)
+	}
+	return encoded, nil
+}
+
+// A document body already encoded with encodeDocument
+type encodedDocument []byte
+
+func (d encodedDocument) MarshalJSON() ([]byte, error) {
+	return d, nil
+}
+
+func batchHeaders(r *Request) error {
+	r.Header.Set(HeaderVersion, BatchVersion)
+	r.Header.Set(HeaderIsBatchRequest, "True")
+	r.Header.Set(HeaderBatchAtomic, "True")
+	return nil
+}
+
+// Find the operation that caused the rollback in the results reported with the error
+func newBatchError(reqErr *RequestError) *BatchError {
+	batchErr := &BatchError{Index: -1, RequestError: reqErr}
+	if Serialization.Unmarshal(reqErr.body, &batchErr.Results) != nil {
+		batchErr.Results = nil
+		return batchErr
+	}
+	for i, result := range batchErr.Results {
+		if result.StatusCode >= http.StatusBadRequest && result.StatusCode != http.StatusFailedDependency {
+			batchErr.Index = i
+			break
+		}
+	}
+	return batchErr
+}
@@ -0,0 +1,77 @@
+package documentdb
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExecuteBatch(t *testing.T) {
+	s, c := BulkServerFactory(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/coll/docs/", r.URL.Path)
+		assert.Equal(t, BatchVersion, r.Header.Get(HeaderVersion))
+		assert.Equal(t, "True", r.Header.Get(HeaderIsBatchRequest))
+		assert.Equal(t, "True", r.Header.Get(HeaderBatchAtomic))
+		assert.Equal(t, `["pk"]`, r.Header.Get(HeaderPartitionKey))
+		var ops []BatchOperation
+		b, _ := ioutil.ReadAll(r.Body)
+		json.Unmarshal(b, &ops)
+		assert.Len(t, ops, 2)
+		fmt.Fprint(w, `[{"statusCode": 201, "requestCharge": 5.5, "eTag": "e1", "resourceBody": {"id": "1"}}, {"statusCode": 204}]`)
+	})
+	defer s.Close()
+
+	results, r, err := c.ExecuteBatch("coll/", "pk", []BatchOperation{
+		{OperationType: BatchCreate, ResourceBody: &Document{Resource: Resource{Id: "1"}}},
+		{OperationType: BatchDelete, Id: "2"},
+	})
+	assert.NoError(t, err)
+	assert.NotNil(t, r)
+	if assert.Len(t, results, 2) {
+		assert.Equal(t, http.StatusCreated, results[0].StatusCode)
+		assert.Equal(t, 5.5, results[0].RequestCharge)
+		assert.JSONEq(t, `{"id": "1"}`, string(results[0].ResourceBody))
+		assert.Equal(t, http.StatusNoContent, results[1].StatusCode)
+	}
+}
+
+func TestExecuteBatchFailure(t *testing.T) {
+	s, c := BulkServerFactory(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusConflict)
+		fmt.Fprint(w, `[{"statusCode": 424}, {"statusCode": 409}, {"statusCode": 424}]`)
+	})
+	defer s.Close()
+
+	results, _, err := c.ExecuteBatch("coll/", "pk", []BatchOperation{
+		{OperationType: BatchCreate, ResourceBody: &Document{}},
+		{OperationType: BatchCreate, ResourceBody: &Document{}},
+		{OperationType: BatchCreate, ResourceBody: &Document{}},
+	})
+	assert.Nil(t, results)
+	batchErr, ok := err.(*BatchError)
+	if assert.True(t, ok, "should return a BatchError") {
+		assert.Equal(t, 1, batchErr.Index)
+		assert.Len(t, batchErr.Results, 3)
+		assert.Equal(t, http.StatusConflict, batchErr.StatusCode)
+		assert.Contains(t, batchErr.Error(), "batch operation 1 failed with status 409")
+	}
+}
+
+func TestExecuteBatchFailureWithoutResults(t *testing.T) {
+	s, c := BulkServerFactory(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"code": "BadRequest", "message": "Batch request has more operations than what is supported."}`, http.StatusBadRequest)
+	})
+	defer s.Close()
+
+	_, _, err := c.ExecuteBatch("coll/", "pk", []BatchOperation{{OperationType: BatchRead, Id: "1"}})
+	batchErr, ok := err.(*BatchError)
+	if assert.True(t, ok, "should return a BatchError") {
+		assert.Equal(t, -1, batchErr.Index)
+		assert.Nil(t, batchErr.Results)
+		assert.Equal(t, "BadRequest", batchErr.Code)
+	}
+}
@@ -0,0 +1,390 @@
+package documentdb
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultBulkConcurrency is the number of operations executed in parallel by the bulk helpers
+	DefaultBulkConcurrency = 4
+
+	// DefaultBulkMaxRetries is the number of times a throttled operation is retried by the bulk helpers
+	DefaultBulkMaxRetries = 5
+
+	// DefaultBulkBackoff is the initial delay before retrying a throttled operation
+	DefaultBulkBackoff = 100 * time.Millisecond
+)
+
+// BulkOptions configures the bulk helpers, zero values fallback to the defaults
+type BulkOptions struct {
+	// Concurrency is the number of operations executed in parallel
+	Concurrency int
+	// MaxRetries is the number of times a throttled(429) operation is retried
+	MaxRetries int
+	// Backoff is the initial delay before retrying a throttled operation, it doubles on every retry
+	Backoff time.Duration
+	// SummaryOnly skips collecting per item results, only the BulkSummary counters are filled.
+	// Use it for very large batches when only the totals are needed.
+	SummaryOnly bool
+	// PartitionBatching makes BulkUpsert group the documents by partition key, and upsert every group
+	// with transactional batches(see ExecuteBatch) of up to MaxBatchOperations documents and MaxBatchSize
+	// bytes instead of one request per document. Documents too large for a batch, and the ones left alone
+	// in their partition, are upserted on their own. The collection must be partitioned.
+	// A failing document rolls back its whole batch, see BulkResult.Batches.
+	PartitionBatching bool
+	// NoSplit disables splitting the batches rejected with ErrPayloadTooLarge. By default such a batch
+	// is split in halves which are sent again, down to single documents, when PartitionBatching is set.
+	NoSplit bool
+}
+
+func (o *BulkOptions) concurrency() int {
+	if o.Concurrency <= 0 {
+		return DefaultBulkConcurrency
+	}
+	return o.Concurrency
+}
+
+func (o *BulkOptions) maxRetries() int {
+	if o.MaxRetries <= 0 {
+		return DefaultBulkMaxRetries
+	}
+	return o.MaxRetries
+}
+
+func (o *BulkOptions) backoff() time.Duration {
+	if o.Backoff <= 0 {
+		return DefaultBulkBackoff
+	}
+	return o.Backoff
+}
+
+// BulkItemResult holds the outcome of a single bulk operation
+type BulkItemResult struct {
+	Index    int
+	Response *Response
+	Err      error
+}
+
+// BulkSummary aggregates the outcome of a bulk operation
+type BulkSummary struct {
+	Succeeded int
+	Failed    int
+	// Throttled counts the throttled attempts, including the ones that succeeded on retry
+	Throttled int
+	// RequestCharge is the total request units consumed by the succeeded operations
+	RequestCharge float64
+}
+
+// BulkBatchResult holds the outcome of a transactional batch sent by BulkUpsert, see BulkOptions.PartitionBatching
+type BulkBatchResult struct {
+	// Indexes of the documents sent in the batch
+	Indexes []int
+	// Results of the batch operations, in the order of Indexes
+	Results  []BatchOperationResult
+	Response *Response
+	// Err is a *BatchError when the batch was rolled back
+	Err error
+}
+
+// BulkResult holds the outcome of a bulk helper
+type BulkResult struct {
+	BulkSummary
+	// Items is index-aligned with the input: Items[i] holds the outcome of the i-th document(or link),
+	// whatever the order the operations completed in, so failures can be matched to their source.
+	// It's nil when BulkOptions.SummaryOnly is set.
+	// Documents sent in a transactional batch share its Response, and its error if it was rolled back.
+	Items []BulkItemResult
+	// Batches is set only with BulkOptions.PartitionBatching, and is nil when BulkOptions.SummaryOnly is set
+	Batches []BulkBatchResult
+}
+
+// BulkCreate creates the documents in parallel, see BulkOptions
+func (c *DocumentDB) BulkCreate(ctx context.Context, coll string, docs []interface{}, opts *BulkOptions, callOpts ...CallOption) (*BulkResult, error) {
+	callOpts = throttledOptions(ctx, callOpts)
+	return c.bulk(ctx, "Create", len(docs), opts, func(i int) (*Response, error) {
+		return c.CreateDocument(coll, docs[i], callOpts...)
+	})
+}
+
+// BulkUpsert upserts the documents in parallel, see BulkOptions
+func (c *DocumentDB) BulkUpsert(ctx context.Context, coll string, docs []interface{}, opts *BulkOptions, callOpts ...CallOption) (*BulkResult, error) {
+	callOpts = throttledOptions(ctx, callOpts)
+	if opts != nil && opts.PartitionBatching {
+		return c.bulkUpsertBatches(ctx, coll, docs, opts, callOpts)
+	}
+	return c.bulk(ctx, "Upsert", len(docs), opts, func(i int) (*Response, error) {
+		return c.UpsertDocument(coll, docs[i], callOpts...)
+	})
+}
+
+// BulkDelete deletes the documents by their self links in parallel, see BulkOptions
+func (c *DocumentDB) BulkDelete(ctx context.Context, links []string, opts *BulkOptions, callOpts ...CallOption) (*BulkResult, error) {
+	callOpts = throttledOptions(ctx, callOpts)
+	return c.bulk(ctx, "Delete", len(links), opts, func(i int) (*Response, error) {
+		return c.DeleteDocument(links[i], callOpts...)
+	})
+}
+
+// Run n operations in parallel and aggregate their results
+func (c *DocumentDB) bulk(ctx context.Context, operation string, n int, opts *BulkOptions, op func(i int) (*Response, error)) (*BulkResult, error) {
+	if opts == nil {
+		opts = &BulkOptions{}
+	}
+	var (
+		mu     sync.Mutex
+		result = &BulkResult{}
+	)
+	if !opts.SummaryOnly {
+		result.Items = make([]BulkItemResult, n)
+	}
+	runParallel(n, opts.concurrency(), func(i int) {
+		var resp *Response
+		throttled, err := retryThrottled(ctx, opts.maxRetries(), opts.backoff(), func() (err error) {
+			resp, err = op(i)
+			return err
+		}, func() {
+			c.observeRetry(operation, RetryReasonThrottled)
+		})
+
+		mu.Lock()
+		defer mu.Unlock()
+		result.Throttled += throttled
+		if err != nil {
+			result.Failed++
+		} else {
+			result.Succeeded++
+			result.RequestCharge += resp.RequestCharge()
+		}
+		if result.Items != nil {
+			result.Items[i] = BulkItemResult{Index: i, Response: resp, Err: err}
+		}
+	})
+	return result, ctx.Err()
+}
+
+// A group of documents upserted together: a transactional batch, or a single document
+type bulkBatch struct {
+	pk      interface{}
+	indexes []int
+	size    int
+}
+
+// Upsert the documents with transactional batches per partition key, see BulkOptions.PartitionBatching
+func (c *DocumentDB) bulkUpsertBatches(ctx context.Context, coll string, docs []interface{}, opts *BulkOptions, callOpts []CallOption) (*BulkResult, error) {
+	def, err := c.ReadPartitionKeyDefinition(coll)
+	if err != nil {
+		return nil, err
+	}
+	if len(def.Paths) == 0 {
+		return nil, ErrNotPartitioned
+	}
+	result := &BulkResult{}
+	if !opts.SummaryOnly {
+		result.Items = make([]BulkItemResult, len(docs))
+	}
+	var mu sync.Mutex
+	setItem := func(i int, resp *Response, err error) {
+		if err != nil {
+			result.Failed++
+		} else {
+			result.Succeeded++
+		}
+		if result.Items != nil {
+			result.Items[i] = BulkItemResult{Index: i, Response: resp, Err: err}
+		}
+	}
+
+	var (
+		batches []*bulkBatch
+		open    = make(map[string]*bulkBatch)
+		encoded = make([]encodedDocument, len(docs))
+	)
+	for i, doc := range docs {
+		if c.config != nil && c.config.IdentificationHydrator != nil {
+			c.config.IdentificationHydrator(c.config, doc)
+		}
+		pk, err := ValidatePartitionKey(doc, def.Paths[0])
+		if err != nil {
+			setItem(i, nil, err)
+			continue
+		}
+		data, err := encodeDocument(c.config, doc)
+		if err != nil {
+			setItem(i, nil, err)
+			continue
+		}
+		encoded[i] = data
+		// Leave room for the operation envelope
+		size := len(data) + 64
+		if size > MaxBatchSize {
+			batches = append(batches, &bulkBatch{pk: pk, indexes: []int{i}})
+			continue
+		}
+		// Group by the json of the value, so that 1 and "1" are different keys
+		key, err := stringify(pk)
+		if err != nil {
+			setItem(i, nil, err)
+			continue
+		}
+		b := open[string(key)]
+		if b == nil || len(b.indexes) == MaxBatchOperations || b.size+size > MaxBatchSize {
+			b = &bulkBatch{pk: pk}
+			open[string(key)] = b
+			batches = append(batches, b)
+		}
+		b.indexes = append(b.indexes, i)
+		b.size += size
+	}
+
+	var upsert func(b *bulkBatch)
+	upsert = func(b *bulkBatch) {
+		if len(b.indexes) == 1 {
+			i := b.indexes[0]
+			var resp *Response
+			throttled, err := retryThrottled(ctx, opts.maxRetries(), opts.backoff(), func() (err error) {
+				resp, err = c.UpsertDocument(coll, docs[i], append(callOpts, PartitionKey(b.pk))...)
+				return err
+			}, func() {
+				c.observeRetry("Upsert", RetryReasonThrottled)
+			})
+			mu.Lock()
+			defer mu.Unlock()
+			result.Throttled += throttled
+			if err == nil {
+				result.RequestCharge += resp.RequestCharge()
+			}
+			setItem(i, resp, err)
+			return
+		}
+
+		ops := make([]BatchOperation, len(b.indexes))
+		for j, i := range b.indexes {
+			ops[j] = BatchOperation{OperationType: BatchUpsert, ResourceBody: encoded[i]}
+		}
+		var (
+			results []BatchOperationResult
+			resp    *Response
+		)
+		throttled, err := retryThrottled(ctx, opts.maxRetries(), opts.backoff(), func() (err error) {
+			results, resp, err = c.ExecuteBatch(coll, b.pk, ops, callOpts...)
+			return err
+		}, func() {
+			c.observeRetry("Batch", RetryReasonThrottled)
+		})
+		// The size estimate was off, send each half on its own
+		if !opts.NoSplit && errors.Is(err, ErrPayloadTooLarge) {
+			mu.Lock()
+			result.Throttled += throttled
+			mu.Unlock()
+			half := len(b.indexes) / 2
+			upsert(&bulkBatch{pk: b.pk, indexes: b.indexes[:half:half]})
+			upsert(&bulkBatch{pk: b.pk, indexes: b.indexes[half:]})
+			return
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		result.Throttled += throttled
+		if err == nil {
+			result.RequestCharge += resp.RequestCharge()
+		} else if batchErr, ok := err.(*BatchError); ok {
+			results = batchErr.Results
+		}
+		if result.Items != nil {
+			result.Batches = append(result.Batches, BulkBatchResult{Indexes: b.indexes, Results: results, Response: resp, Err: err})
+		}
+		for _, i := range b.indexes {
+			setItem(i, resp, err)
+		}
+	}
+	runParallel(len(batches), opts.concurrency(), func(n int) {
+		upsert(batches[n])
+	})
+	if result.Items != nil {
+		sort.Slice(result.Batches, func(i, j int) bool {
+			return result.Batches[i].Indexes[0] < result.Batches[j].Indexes[0]
+		})
+	}
+	return result, ctx.Err()
+}
+
+// Call fn for every index in [0, n) using a pool of concurrency goroutines
+func runParallel(n, concurrency int, fn func(i int)) {
+	var (
+		wg      sync.WaitGroup
+		indexes = make(chan int)
+	)
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				fn(i)
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+}
+
+// Call fn until it succeeds or fails with anything but throttling, doubling the backoff
+// between attempts. The server suggested delay is used instead when it's longer.
+// If the delay would run past the context deadline the throttling error is returned right away.
+// onRetry is called before every retry. It returns the number of throttled attempts.
+func retryThrottled(ctx context.Context, maxRetries int, backoff time.Duration, fn func() error, onRetry func()) (throttled int, err error) {
+	for attempt := 0; ; attempt++ {
+		if err = ctx.Err(); err != nil {
+			return
+		}
+		if err = fn(); !isThrottled(err) {
+			return
+		}
+		if throttled++; attempt == maxRetries {
+			return
+		}
+		delay := backoff
+		if reqErr, ok := asRequestError(err); ok && reqErr.RetryAfter > delay {
+			delay = reqErr.RetryAfter
+		}
+		if exceedsDeadline(ctx, delay) {
+			return
+		}
+		if err = sleep(ctx, delay); err != nil {
+			return
+		}
+		onRetry()
+		backoff *= 2
+	}
+}
+
+// Options of the requests sent by the helpers that retry throttled requests on their own(see
+// retryThrottled): they run in the background with the helper context, and the client doesn't
+// retry their throttled attempts.
+// The options are shared by the concurrent requests, so their capacity is trimmed to make every append copy them.
+func throttledOptions(ctx context.Context, opts []CallOption) []CallOption {
+	opts = append(lowPriority(opts), WithContext(ctx), func(r *Request) error {
+		r.manualThrottling = true
+		return nil
+	})
+	return opts[:len(opts):len(opts)]
+}
+
+// Check if the request was rejected due to rate limiting
+func isThrottled(err error) bool {
+	reqErr, ok := asRequestError(err)
+	return ok && reqErr.StatusCode == http.StatusTooManyRequests
+}
+
+// Find the RequestError in the error chain, e.g: in a *BatchError
+func asRequestError(err error) (*RequestError, bool) {
+	var reqErr *RequestError
+	return reqErr, errors.As(err, &reqErr)
+}
@@ -0,0 +1,334 @@
+package documentdb
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func BulkServerFactory(handler func(w http.ResponseWriter, r *http.Request)) (*httptest.Server, *DocumentDB) {
+	var mu sync.Mutex
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		handler(w, r)
+	}))
+	return s, New(s.URL, NewConfig(&Key{Key: "YXJpZWwNCg=="}))
+}
+
+func TestBulkCreate(t *testing.T) {
+	throttled := false
+	s, c := BulkServerFactory(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, string(PriorityHigh), r.Header.Get(HeaderPriorityLevel), "should opt out of the low priority")
+		if !throttled {
+			throttled = true
+			http.Error(w, `{"code": "429", "message": "Request rate is large"}`, http.StatusTooManyRequests)
+			return
+		}
+		w.Header().Set(HeaderRequestCharge, "1.5")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{}`))
+	})
+	defer s.Close()
+
+	docs := []interface{}{&Document{}, &Document{}, &Document{}}
+	result, err := c.BulkCreate(context.Background(), "coll/", docs, &BulkOptions{Backoff: time.Millisecond}, Priority(PriorityHigh))
+	assert.NoError(t, err)
+	assert.Equal(t, BulkSummary{Succeeded: 3, Throttled: 1, RequestCharge: 4.5}, result.BulkSummary)
+	if assert.Len(t, result.Items, 3) {
+		for i, item := range result.Items {
+			assert.Equal(t, i, item.Index)
+			assert.NoError(t, item.Err)
+		}
+	}
+}
+
+func TestBulkDeleteSummaryOnly(t *testing.T) {
+	s, c := BulkServerFactory(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodDelete, r.Method)
+		assert.Equal(t, string(PriorityLow), r.Header.Get(HeaderPriorityLevel))
+		if strings.HasSuffix(r.URL.Path, "/missing") {
+			http.Error(w, `{"code": "NotFound", "message": "Resource Not Found"}`, http.StatusNotFound)
+			return
+		}
+		w.Header().Set(HeaderRequestCharge, "2")
+		w.WriteHeader(http.StatusNoContent)
+	})
+	defer s.Close()
+
+	links := []string{"coll/docs/1", "coll/docs/missing", "coll/docs/2"}
+	result, err := c.BulkDelete(context.Background(), links, &BulkOptions{Concurrency: 2, SummaryOnly: true})
+	assert.NoError(t, err)
+	assert.Nil(t, result.Items)
+	assert.Equal(t, BulkSummary{Succeeded: 2, Failed: 1, RequestCharge: 4}, result.BulkSummary)
+}
+
+func TestBulkUpsertCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	c := New("http://localhost", NewConfig(&Key{Key: "YXJpZWwNCg=="}))
+	result, err := c.BulkUpsert(ctx, "coll/", []interface{}{&Document{}}, nil)
+	assert.Equal(t, context.Canceled, err)
+	assert.Equal(t, 1, result.Failed)
+	assert.Equal(t, context.Canceled, result.Items[0].Err)
+}
+
+func TestBulkCreateCancelledDuringRetryDelay(t *testing.T) {
+	s, c := BulkServerFactory(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(HeaderRetryAfter, "60000")
+		http.Error(w, `{"code": "429", "message": "Request rate is large"}`, http.StatusTooManyRequests)
+	})
+	defer s.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+	start := time.Now()
+	result, err := c.BulkCreate(ctx, "coll/", []interface{}{&Document{}}, nil)
+	assert.Equal(t, context.Canceled, err)
+	assert.Equal(t, context.Canceled, result.Items[0].Err)
+	assert.Equal(t, 1, result.Throttled)
+	assert.True(t, time.Since(start) < time.Second, "should not wait for the server suggested delay")
+}
+
+func TestBulkCreateRetryDelayPastDeadline(t *testing.T) {
+	var calls int
+	s, c := BulkServerFactory(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set(HeaderRetryAfter, "60000")
+		http.Error(w, `{"code": "429", "message": "Request rate is large"}`, http.StatusTooManyRequests)
+	})
+	defer s.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	start := time.Now()
+	result, err := c.BulkCreate(ctx, "coll/", []interface{}{&Document{}}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, calls, "should not retry when the delay exceeds the deadline")
+	assert.True(t, isThrottled(result.Items[0].Err))
+	assert.True(t, time.Since(start) < time.Second, "should fail fast")
+}
+
+func TestBulkUpsertResultsOrder(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var doc Document
+		json.NewDecoder(r.Body).Decode(&doc)
+		n, _ := strconv.Atoi(doc.Id)
+		// Complete the later documents first
+		time.Sleep(time.Duration(10-n) * time.Millisecond)
+		if n%2 == 1 {
+			http.Error(w, `{"code": "400", "message": "Bad request"}`, http.StatusBadRequest)
+			return
+		}
+		w.Header().Set(HeaderActivityID, doc.Id)
+		json.NewEncoder(w).Encode(doc)
+	}))
+	defer s.Close()
+	c := New(s.URL, NewConfig(&Key{Key: "YXJpZWwNCg=="}))
+
+	docs := make([]interface{}, 10)
+	for i := range docs {
+		docs[i] = &Document{Resource: Resource{Id: strconv.Itoa(i)}}
+	}
+	result, err := c.BulkUpsert(context.Background(), "coll/", docs, &BulkOptions{Concurrency: 10})
+	assert.NoError(t, err)
+	assert.Equal(t, 5, result.Failed)
+	for i, item := range result.Items {
+		assert.Equal(t, i, item.Index)
+		if i%2 == 1 {
+			assert.Error(t, item.Err, "item %d should hold the failure of document %d", i, i)
+		} else if assert.NoError(t, item.Err) {
+			assert.Equal(t, strconv.Itoa(i), item.Response.Header.Get(HeaderActivityID))
+		}
+	}
+}
+
+func TestBulkUpsertSharedOptions(t *testing.T) {
+	s, c := BulkServerFactory(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "true", r.Header.Get(HeaderUpsert))
+		assert.Equal(t, "token", r.Header.Get(HeaderSessionToken))
+		w.Write([]byte(`{}`))
+	})
+	defer s.Close()
+
+	docs := make([]interface{}, 20)
+	for i := range docs {
+		docs[i] = &Document{Resource: Resource{Id: strconv.Itoa(i)}}
+	}
+	// Enough options to leave spare capacity to the appends of the concurrent upserts
+	callOpts := []CallOption{ConsistencyLevel(Session), SessionToken("token"), Idempotent(true)}
+	result, err := c.BulkUpsert(context.Background(), "coll/", docs, &BulkOptions{Concurrency: 10}, callOpts...)
+	assert.NoError(t, err)
+	assert.Equal(t, 20, result.Succeeded)
+}
+
+func PartitionedBulkServerFactory(batch func(w http.ResponseWriter, pk string, ops []BatchOperation)) (*httptest.Server, *DocumentDB, *[]string) {
+	var upserts []string
+	s, c := BulkServerFactory(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet:
+			w.Write([]byte(`{"id": "coll", "partitionKey": {"paths": ["/tenant"], "kind": "Hash"}}`))
+		case r.Header.Get(HeaderIsBatchRequest) == "True":
+			var ops []BatchOperation
+			json.NewDecoder(r.Body).Decode(&ops)
+			batch(w, r.Header.Get(HeaderPartitionKey), ops)
+		default:
+			var doc map[string]interface{}
+			json.NewDecoder(r.Body).Decode(&doc)
+			upserts = append(upserts, doc["id"].(string))
+			w.Header().Set(HeaderRequestCharge, "1")
+			json.NewEncoder(w).Encode(doc)
+		}
+	})
+	return s, c, &upserts
+}
+
+func TestBulkUpsertPartitionBatching(t *testing.T) {
+	s, c, upserts := PartitionedBulkServerFactory(func(w http.ResponseWriter, pk string, ops []BatchOperation) {
+		results := make([]BatchOperationResult, len(ops))
+		for i := range results {
+			results[i].StatusCode = http.StatusOK
+		}
+		if pk == `["b"]` {
+			results[0].StatusCode = http.StatusFailedDependency
+			results[1].StatusCode = http.StatusBadRequest
+			w.WriteHeader(http.StatusBadRequest)
+		} else {
+			w.Header().Set(HeaderRequestCharge, "2")
+		}
+		json.NewEncoder(w).Encode(results)
+	})
+	defer s.Close()
+
+	docs := []interface{}{
+		map[string]interface{}{"id": "0", "tenant": "a"},
+		map[string]interface{}{"id": "1", "tenant": "b"},
+		map[string]interface{}{"id": "2", "tenant": "a"},
+		map[string]interface{}{"id": "3"},
+		map[string]interface{}{"id": "4", "tenant": "c"},
+		map[string]interface{}{"id": "5", "tenant": "b"},
+	}
+	result, err := c.BulkUpsert(context.Background(), "coll/", docs, &BulkOptions{PartitionBatching: true})
+	assert.NoError(t, err)
+	assert.Equal(t, BulkSummary{Succeeded: 3, Failed: 3, RequestCharge: 3}, result.BulkSummary)
+	assert.Equal(t, []string{"4"}, *upserts)
+	if assert.Len(t, result.Batches, 2) {
+		assert.Equal(t, []int{0, 2}, result.Batches[0].Indexes)
+		assert.NoError(t, result.Batches[0].Err)
+		assert.Equal(t, []int{1, 5}, result.Batches[1].Indexes)
+		if batchErr, ok := result.Batches[1].Err.(*BatchError); assert.True(t, ok) {
+			assert.Equal(t, 1, batchErr.Index)
+		}
+	}
+	for i, item := range result.Items {
+		assert.Equal(t, i, item.Index)
+		switch i {
+		case 1, 5:
+			assert.IsType(t, &BatchError{}, item.Err)
+		case 3:
+			assert.EqualError(t, item.Err, "partition key /tenant is missing in document")
+		default:
+			assert.NoError(t, item.Err)
+		}
+	}
+}
+
+func TestBulkUpsertPartitionBatchingLimits(t *testing.T) {
+	var sizes []int
+	s, c, _ := PartitionedBulkServerFactory(func(w http.ResponseWriter, pk string, ops []BatchOperation) {
+		sizes = append(sizes, len(ops))
+		results := make([]BatchOperationResult, len(ops))
+		json.NewEncoder(w).Encode(results)
+	})
+	defer s.Close()
+
+	docs := make([]interface{}, MaxBatchOperations+50)
+	for i := range docs {
+		docs[i] = map[string]interface{}{"id": strconv.Itoa(i), "tenant": "a"}
+	}
+	result, err := c.BulkUpsert(context.Background(), "coll/", docs, &BulkOptions{PartitionBatching: true, SummaryOnly: true})
+	assert.NoError(t, err)
+	assert.Equal(t, len(docs), result.Succeeded)
+	assert.Nil(t, result.Batches)
+	assert.ElementsMatch(t, []int{MaxBatchOperations, 50}, sizes)
+}
+
+func TestBulkUpsertPartitionBatchingEncoding(t *testing.T) {
+	var stored []map[string]interface{}
+	s, c, _ := PartitionedBulkServerFactory(func(w http.ResponseWriter, pk string, ops []BatchOperation) {
+		for _, op := range ops {
+			stored = append(stored, op.ResourceBody.(map[string]interface{}))
+		}
+		json.NewEncoder(w).Encode(make([]BatchOperationResult, len(ops)))
+	})
+	defer s.Close()
+	c.config.FieldCodecs = map[string]FieldCodec{"/payload": reverseCodec{}}
+	c.config.SystemPropertyNames = map[string]string{"id": "key"}
+
+	docs := []interface{}{
+		map[string]interface{}{"key": "0", "tenant": "a", "payload": "x"},
+		map[string]interface{}{"key": "1", "tenant": "a", "payload": "y"},
+	}
+	result, err := c.BulkUpsert(context.Background(), "coll/", docs, &BulkOptions{PartitionBatching: true})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, result.Succeeded)
+	// The batched documents are written like the ones upserted on their own
+	assert.Equal(t, []map[string]interface{}{
+		{"id": "0", "tenant": "a", "payload": base64.StdEncoding.EncodeToString([]byte(`"x"`))},
+		{"id": "1", "tenant": "a", "payload": base64.StdEncoding.EncodeToString([]byte(`"y"`))},
+	}, stored)
+}
+
+func TestBulkUpsertPartitionBatchingNotPartitioned(t *testing.T) {
+	s, c := BulkServerFactory(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"id": "coll", "partitionKey": {"paths": [], "kind": "Hash"}}`))
+	})
+	defer s.Close()
+	_, err := c.BulkUpsert(context.Background(), "coll/", []interface{}{map[string]interface{}{"id": "0"}}, &BulkOptions{PartitionBatching: true})
+	assert.Equal(t, ErrNotPartitioned, err)
+}
+
+func TestBulkUpsertPartitionBatchingSplit(t *testing.T) {
+	var sizes []int
+	s, c, upserts := PartitionedBulkServerFactory(func(w http.ResponseWriter, pk string, ops []BatchOperation) {
+		sizes = append(sizes, len(ops))
+		if len(ops) > 2 {
+			http.Error(w, `{"code": "RequestEntityTooLarge", "message": "Request size is too large"}`, http.StatusRequestEntityTooLarge)
+			return
+		}
+		json.NewEncoder(w).Encode(make([]BatchOperationResult, len(ops)))
+	})
+	defer s.Close()
+
+	docs := make([]interface{}, 5)
+	for i := range docs {
+		docs[i] = map[string]interface{}{"id": strconv.Itoa(i), "tenant": "a"}
+	}
+	result, err := c.BulkUpsert(context.Background(), "coll/", docs, &BulkOptions{PartitionBatching: true})
+	assert.NoError(t, err)
+	assert.Equal(t, 5, result.Succeeded)
+	// 5 => 2 + 3, 3 => 1 + 2
+	assert.ElementsMatch(t, []int{5, 2, 3, 2}, sizes)
+	assert.Equal(t, []string{"2"}, *upserts)
+	if assert.Len(t, result.Batches, 2) {
+		assert.Equal(t, []int{0, 1}, result.Batches[0].Indexes)
+		assert.Equal(t, []int{3, 4}, result.Batches[1].Indexes)
+	}
+
+	sizes = nil
+	result, err = c.BulkUpsert(context.Background(), "coll/", docs, &BulkOptions{PartitionBatching: true, NoSplit: true})
+	assert.NoError(t, err)
+	assert.Equal(t, 5, result.Failed)
+	assert.Equal(t, []int{5}, sizes)
+	assert.True(t, errors.Is(result.Items[0].Err, ErrPayloadTooLarge))
+}
@@ -0,0 +1,101 @@
+package documentdb
+
+import (
+	"sync"
+	"time"
+)
+
+// DefaultMetadataCacheTTL is the time metadata is kept by the default cache
+const DefaultMetadataCacheTTL = 5 * time.Minute
+
+// MetadataCache caches the partitioning metadata of collections, so it isn't
+// read again on every request. Implementations must be safe for concurrent use.
+type MetadataCache interface {
+	PartitionKeyDefinition(coll string) (PartitionKeyDefinition, bool)
+	SetPartitionKeyDefinition(coll string, def PartitionKeyDefinition)
+	PartitionKeyRanges(coll string) ([]PartitionKeyRange, bool)
+	SetPartitionKeyRanges(coll string, ranges []PartitionKeyRange)
+	// Invalidate drops all the cached metadata of the collection
+	Invalidate(coll string)
+}
+
+type cacheEntry struct {
+	def     *PartitionKeyDefinition
+	ranges  []PartitionKeyRange
+	expires time.Time
+}
+
+// MemoryMetadataCache is an in-memory MetadataCache, entries expire after a TTL
+type MemoryMetadataCache struct {
+	ttl     time.Duration
+	mu      sync.RWMutex
+	entries map[string]*cacheEntry
+}
+
+// NewMemoryMetadataCache creates in-memory cache, entries expire after ttl
+func NewMemoryMetadataCache(ttl time.Duration) *MemoryMetadataCache {
+	return &MemoryMetadataCache{
+		ttl:     ttl,
+		entries: make(map[string]*cacheEntry),
+	}
+}
+
+// Get the entry of a collection if it didn't expire
+func (m *MemoryMetadataCache) get(coll string) *cacheEntry {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if e, ok := m.entries[coll]; ok && time.Now().Before(e.expires) {
+		return e
+	}
+	return nil
+}
+
+// Update the entry of a collection, refreshing its expiration
+func (m *MemoryMetadataCache) set(coll string, update func(e *cacheEntry)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, ok := m.entries[coll]
+	if !ok || time.Now().After(e.expires) {
+		e = &cacheEntry{}
+	}
+	update(e)
+	e.expires = time.Now().Add(m.ttl)
+	m.entries[coll] = e
+}
+
+// PartitionKeyDefinition returns the cached partition key definition of a collection
+func (m *MemoryMetadataCache) PartitionKeyDefinition(coll string) (PartitionKeyDefinition, bool) {
+	if e := m.get(coll); e != nil && e.def != nil {
+		return *e.def, true
+	}
+	return PartitionKeyDefinition{}, false
+}
+
+// SetPartitionKeyDefinition caches the partition key definition of a collection
+func (m *MemoryMetadataCache) SetPartitionKeyDefinition(coll string, def PartitionKeyDefinition) {
+	m.set(coll, func(e *cacheEntry) {
+		e.def = &def
+	})
+}
+
+// PartitionKeyRanges returns the cached partition key ranges of a collection
+func (m *MemoryMetadataCache) PartitionKeyRanges(coll string) ([]PartitionKeyRange, bool) {
+	if e := m.get(coll); e != nil && e.ranges != nil {
+		return e.ranges, true
+	}
+	return nil, false
+}
+
+// SetPartitionKeyRanges caches the partition key ranges of a collection
+func (m *MemoryMetadataCache) SetPartitionKeyRanges(coll string, ranges []PartitionKeyRange) {
+	m.set(coll, func(e *cacheEntry) {
+		e.ranges = ranges
+	})
+}
+
+// Invalidate drops all the cached metadata of the collection
+func (m *MemoryMetadataCache) Invalidate(coll string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.entries, coll)
+}
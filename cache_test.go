@@ -0,0 +1,79 @@
+package documentdb
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestMemoryMetadataCache(t *testing.T) {
+	assert := assert.New(t)
+	cache := NewMemoryMetadataCache(time.Minute)
+
+	_, ok := cache.PartitionKeyRanges("coll")
+	assert.False(ok)
+
+	def := PartitionKeyDefinition{Paths: []string{"/tenant"}, Kind: "Hash"}
+	cache.SetPartitionKeyDefinition("coll", def)
+	cache.SetPartitionKeyRanges("coll", []PartitionKeyRange{{PartitionKeyRangeID: "0"}})
+
+	cached, ok := cache.PartitionKeyDefinition("coll")
+	assert.True(ok)
+	assert.Equal(def, cached)
+	ranges, ok := cache.PartitionKeyRanges("coll")
+	assert.True(ok)
+	assert.Len(ranges, 1)
+
+	cache.Invalidate("coll")
+	_, ok = cache.PartitionKeyDefinition("coll")
+	assert.False(ok)
+}
+
+func TestMemoryMetadataCacheExpiration(t *testing.T) {
+	cache := NewMemoryMetadataCache(time.Millisecond)
+	cache.SetPartitionKeyRanges("coll", []PartitionKeyRange{{PartitionKeyRangeID: "0"}})
+	time.Sleep(5 * time.Millisecond)
+	_, ok := cache.PartitionKeyRanges("coll")
+	assert.False(t, ok, "entry should expire after the ttl")
+}
+
+func TestReadPartitionKeyRangesCached(t *testing.T) {
+	client := &ClientStub{}
+	c := &DocumentDB{client, NewConfig(&Key{Key: "YXJpZWwNCg=="})}
+	client.On("Read", "coll/pkranges/", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		args.Get(1).(*queryPartitionKeyRangesRequest).Ranges = []PartitionKeyRange{{PartitionKeyRangeID: "0"}}
+	}).Return(&Response{}, nil)
+
+	for i := 0; i < 2; i++ {
+		ranges, err := c.ReadPartitionKeyRanges("coll/")
+		assert.NoError(t, err)
+		assert.Len(t, ranges, 1)
+	}
+	client.AssertNumberOfCalls(t, "Read", 1)
+
+	c.invalidateMetadata("coll/")
+	c.ReadPartitionKeyRanges("coll/")
+	client.AssertNumberOfCalls(t, "Read", 2)
+}
+
+func TestReadPartitionKeyDefinition(t *testing.T) {
+	client := &ClientStub{}
+	c := &DocumentDB{client, NewConfig(&Key{Key: "YXJpZWwNCg=="})}
+	client.On("Read", "coll/", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		coll := args.Get(1).(**Collection)
+		*coll = &Collection{PartitionKey: &PartitionKeyDefinition{Paths: []string{"/tenant"}}}
+	}).Return(&Response{}, nil).Once()
+	client.On("Read", "unpartitioned/", mock.Anything, mock.Anything).Return(&Response{}, nil)
+
+	def, err := c.ReadPartitionKeyDefinition("coll/")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"/tenant"}, def.Paths)
+	def, err = c.ReadPartitionKeyDefinition("coll/")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"/tenant"}, def.Paths, "should be served from cache")
+
+	_, err = c.ReadPartitionKeyDefinition("unpartitioned/")
+	assert.Equal(t, ErrNotPartitioned, err)
+}
@@ -0,0 +1,49 @@
+package documentdb
+
+import "sync"
+
+// CheckpointStore persists the progress of a PartitionKeyRangeIterator, the continuation of every
+// partition key range, so a restarted reader resumes each range where it stopped.
+// Implementations must be safe for concurrent use.
+type CheckpointStore interface {
+	// ReadCheckpoint returns the continuation of the range, empty if none was written
+	ReadCheckpoint(coll, rangeID string) (string, error)
+	WriteCheckpoint(coll, rangeID, continuation string) error
+}
+
+// MemoryCheckpointStore is an in-memory CheckpointStore, for tests and readers that resume
+// within the same process
+type MemoryCheckpointStore struct {
+	mu          sync.RWMutex
+	checkpoints map[[2]string]string
+}
+
+// NewMemoryCheckpointStore creates an empty in-memory checkpoint store
+func NewMemoryCheckpointStore() *MemoryCheckpointStore {
+	return &MemoryCheckpointStore{checkpoints: make(map[[2]string]string)}
+}
+
+// ReadCheckpoint returns the continuation of the range, empty if none was written
+func (m *MemoryCheckpointStore) ReadCheckpoint(coll, rangeID string) (string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.checkpoints[[2]string{coll, rangeID}], nil
+}
+
+// WriteCheckpoint stores the continuation of the range
+func (m *MemoryCheckpointStore) WriteCheckpoint(coll, rangeID, continuation string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.checkpoints[[2]string{coll, rangeID}] = continuation
+	return nil
+}
+
+// Return the checkpoint of a range, or of its nearest ancestor if the range was split
+// from it since the last checkpoint
+func readCheckpoint(store CheckpointStore, coll string, r PartitionKeyRange) (string, error) {
+	continuation, err := store.ReadCheckpoint(coll, r.PartitionKeyRangeID)
+	for i := len(r.Parents) - 1; continuation == "" && err == nil && i >= 0; i-- {
+		continuation, err = store.ReadCheckpoint(coll, r.Parents[i])
+	}
+	return continuation, err
+}
@@ -2,8 +2,14 @@ package documentdb
 
 import (
 	"bytes"
+	"context"
 	"io"
+	"io/ioutil"
 	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
 )
 
 type Clienter interface {
@@ -14,36 +20,62 @@ type Clienter interface {
 	Upsert(link string, body, ret interface{}, opts ...CallOption) (*Response, error)
 	Replace(link string, body, ret interface{}, opts ...CallOption) (*Response, error)
 	Execute(link string, body, ret interface{}, opts ...CallOption) (*Response, error)
+	Patch(link string, operations []PatchOperation, ret interface{}, opts ...CallOption) (*Response, error)
 }
 
 type Client struct {
 	Url    string
 	Config *Config
 	http.Client
+	// clockSkew is the offset of the server clock from the local one
+	clockSkew atomic.Int64
+	// throttles tracks the recent throttled responses, see ThrottleRatio
+	throttles throttleWindow
+	// sessions tracks the session tokens of the collections, see Config.AutoTrackSessionToken
+	sessions sessionTokens
+	// tokens caches the token of Config.Credential
+	tokens tokenCache
 }
 
 func (c *Client) apply(r *Request, opts []CallOption) (err error) { 
-	if err = r.DefaultHeaders(c.Config.MasterKey); err != nil {
-		return err
-	}
+	r.Header.Set(HeaderClientRequestID, c.requestID())
+	c.applySession(r)
 
 	for i := 0; i < len(opts); i++ {
 		if err = opts[i](r); err != nil {
 			return err
 		}
 	}
-	return nil
+	// The request is signed last, with the key the options may have set
+	return c.sign(r)
+}
+
+// The key signing the request, see WithMasterKey
+func (c *Client) masterKey(r *Request) *Key {
+	if r.key != nil {
+		return r.key
+	}
+	return c.Config.MasterKey
 }
 
 // Read resource by self link
 func (c *Client) Read(link string, ret interface{}, opts ...CallOption) (*Response, error) {
-	buf := buffers.Get().(*bytes.Buffer)
-	buf.Reset()
-	res, err := c.method(http.MethodGet, link, expectStatusCode(http.StatusOK), ret, buf, opts...)
-
-	buffers.Put(buf)
-
-	return res, err
+	if c.Config.DocumentCache != nil && ret != nil && isDocumentLink(link) {
+		req, err := http.NewRequest(http.MethodGet, c.Url+"/"+link, &bytes.Buffer{})
+		if err != nil {
+			return nil, err
+		}
+		r := ResourceRequest(link, req)
+		if err = c.apply(r, opts); err != nil {
+			return nil, err
+		}
+		// The conditional reads of the caller aren't served from the cache
+		if r.Header.Get(HeaderIfNonMatch) == "" {
+			return c.readCached(r, ret)
+		}
+		return c.do(r, expectStatusCode(http.StatusOK), ret)
+	}
+	return c.method(http.MethodGet, link, expectStatusCode(http.StatusOK), ret, &bytes.Buffer{}, opts...)
 }
 
 // Delete resource by self link
@@ -61,29 +93,34 @@ func (c *Client) Query(link string, query *Query, ret interface{}, opts ...CallO
 	buf.Reset()
 	defer buffers.Put(buf)
 
+	if format := c.Config.TimeFormat; format != TimeFormatRFC3339 && query != nil {
+		query = query.encodeParameters(valueEncoding{timeFormat: format})
+	}
 	if err = Serialization.EncoderFactory(buf).Encode(query); err != nil {
 		return nil, err
 
 	}
 
-	req, err = http.NewRequest(http.MethodPost, c.Url+"/"+link, buf)
+	// The transport may still read the body after the response is returned(and the
+	// buffer is back in the pool), so the request gets its own copy of the bytes
+	body := append([]byte(nil), buf.Bytes()...)
+	req, err = http.NewRequest(http.MethodPost, c.Url+"/"+link, bytes.NewReader(body))
 	if err != nil {
 		return nil, err
 	}
 	r := ResourceRequest(link, req)
+	r.QueryHeaders(len(body))
 
 	if err = c.apply(r, opts); err != nil {
 		return nil, err
 	}
 
-	r.QueryHeaders(buf.Len())
-
 	return c.do(r, expectStatusCode(http.StatusOK), ret)
 }
 
 // Create resource
 func (c *Client) Create(link string, body, ret interface{}, opts ...CallOption) (*Response, error) {
-	data, err := stringify(body)
+	data, err := c.encode(body)
 	if err != nil {
 		return nil, err
 	}
@@ -94,7 +131,7 @@ func (c *Client) Create(link string, body, ret interface{}, opts ...CallOption)
 // Upsert resource
 func (c *Client) Upsert(link string, body, ret interface{}, opts ...CallOption) (*Response, error) {
 	opts = append(opts, Upsert())
-	data, err := stringify(body)
+	data, err := c.encode(body)
 	if err != nil {
 		return nil, err
 	}
@@ -104,7 +141,7 @@ func (c *Client) Upsert(link string, body, ret interface{}, opts ...CallOption)
 
 // Replace resource
 func (c *Client) Replace(link string, body, ret interface{}, opts ...CallOption) (*Response, error) {
-	data, err := stringify(body)
+	data, err := c.encode(body)
 	if err != nil {
 		return nil, err
 	}
@@ -112,6 +149,28 @@ func (c *Client) Replace(link string, body, ret interface{}, opts ...CallOption)
 	return c.method(http.MethodPut, link, expectStatusCode(http.StatusOK), ret, buf, opts...)
 }
 
+// Patch resource, see PatchDocument
+func (c *Client) Patch(link string, operations []PatchOperation, ret interface{}, opts ...CallOption) (*Response, error) {
+	if format := c.Config.TimeFormat; format != TimeFormatRFC3339 {
+		encoded := make([]PatchOperation, len(operations))
+		for i, op := range operations {
+			op.Value = applyValueEncoding(op.Value, valueEncoding{timeFormat: format})
+			encoded[i] = op
+		}
+		operations = encoded
+	}
+	operations, err := encodePatchOperations(operations, c.Config.FieldCodecs)
+	if err != nil {
+		return nil, err
+	}
+	data, err := stringify(patchRequest{operations})
+	if err != nil {
+		return nil, err
+	}
+	buf := bytes.NewBuffer(data)
+	return c.method(http.MethodPatch, link, expectStatusCode(http.StatusOK), ret, buf, opts...)
+}
+
 // Replace resource
 // TODO: DRY, move to methods instead of actions(POST, PUT, ...)
 func (c *Client) Execute(link string, body, ret interface{}, opts ...CallOption) (*Response, error) {
@@ -131,6 +190,12 @@ func (c *Client) method(method string, link string, validator statusCodeValidato
 	}
 
 	r := ResourceRequest(link, req)
+	switch method {
+	case http.MethodPost, http.MethodPut:
+		r.Header.Set(HeaderContentType, ContentTypeJSON)
+	case http.MethodPatch:
+		r.Header.Set(HeaderContentType, ContentTypeJSONPatch)
+	}
 
 	if err = c.apply(r, opts); err != nil {
 		return nil, err
@@ -141,25 +206,261 @@ func (c *Client) method(method string, link string, validator statusCodeValidato
 
 // Private Do function, DRY
 func (c *Client) do(r *Request, validator statusCodeValidatorFunc, data interface{}) (*Response, error) {
-	resp, err := c.Do(r.Request)
+	span, start := c.startSpan(r), time.Now()
+	resp, err := c.roundTrip(r, validator, data)
+	c.observeCall(r, err, time.Since(start))
+	endSpan(span, err)
+	return resp, err
+}
+
+// Send the request until it succeeds or can't be retried, and decode its response into data
+func (c *Client) roundTrip(r *Request, validator statusCodeValidatorFunc, data interface{}) (*Response, error) {
+	var (
+		resp        *http.Response
+		cancel      context.CancelFunc
+		err         error
+		retries     int
+		clockSynced bool
+		// throttleWait is the total time waited for throttling retries, see RetryOptions.MaxRetryWaitTime
+		throttleWait time.Duration
+	)
+	for attempt := 1; ; attempt++ {
+		c.logger().Debugf("documentdb: attempt %d outgoing request %s %s, request id %s", attempt, r.Method, r.URL.Path, r.requestID())
+		resp, cancel, err = c.send(r, attempt)
+		if err == nil {
+			if validator(resp.StatusCode) {
+				break
+			}
+			reqErr := newRequestError(resp)
+			reqErr.RequestID = r.requestID()
+			reqErr.DiagnosticContext = r.diagnosticContext
+			err = reqErr
+		}
+		cancel()
+		// A cancelled request isn't retried, and fails with the context error
+		if ctxErr := r.Context().Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		var reason string
+		reqErr, _ := err.(*RequestError)
+		switch retry := c.Config.RetryOptions.forError(err); {
+		// A request rejected for clock skew is signed again with the server clock, once
+		case reqErr != nil && !clockSynced && isClockSkew(reqErr) && c.syncClock(resp.Header.Get("Date")):
+			clockSynced, reason = true, RetryReasonClockSkew
+			r.traceRetry(attempt, reason, 0)
+		// A Create that may have been applied is read back, and retried only if it wasn't
+		case retries < retry.MaxRetries && r.shouldVerify(err):
+			created, verifyErr := c.verifyCreated(r, data)
+			if verifyErr != nil {
+				return nil, err
+			}
+			if created != nil {
+				return created, nil
+			}
+			fallthrough
+		case retries < retry.MaxRetries && r.shouldRetry(err):
+			delay := retry.delay(retries, err)
+			if exceedsDeadline(r.Context(), delay) {
+				return nil, err
+			}
+			reason = RetryReasonTransient
+			if reqErr != nil && reqErr.StatusCode == http.StatusGone {
+				c.invalidateMetadata(r)
+				reason = RetryReasonPartitionGone
+			}
+			if reqErr != nil && reqErr.StatusCode == http.StatusTooManyRequests {
+				if throttleWait += delay; throttleWait > retry.MaxRetryWaitTime {
+					return nil, err
+				}
+				reason = RetryReasonThrottled
+			}
+			r.traceRetry(attempt, reason, delay)
+			if ctxErr := sleep(r.Context(), delay); ctxErr != nil {
+				return nil, ctxErr
+			}
+			retries++
+		default:
+			return nil, err
+		}
+		if reason == RetryReasonClockSkew {
+			c.logger().Infof("documentdb: clock synchronized with the server, skew %v", time.Duration(c.clockSkew.Load()).Round(time.Second))
+		} else {
+			c.logger().Warnf("documentdb: %s %s failed, retrying: %v", r.Method, r.URL.Path, err)
+		}
+		if err = c.sign(r); err != nil {
+			return nil, err
+		}
+		if err = r.rewind(); err != nil {
+			return nil, err
+		}
+		c.observeRetry(r, reason)
+	}
+	defer cancel()
+	defer closeBody(resp.Body)
+	// A 304 response to a conditional read has no body to decode
+	if data == nil || resp.StatusCode == http.StatusNotModified {
+		return &Response{Header: resp.Header}, nil
+	}
+	fallback, driver := c.Config.FallbackSerialization, r.serialization()
+	if r.decoder != nil {
+		fallback = nil
+	}
+	names := c.Config.SystemPropertyNames
+	// Only the documents are renamed, the models of the other resources(e.g: Collection) use the standard names
+	if r.rType != "docs" {
+		names = nil
+	}
+	codecs := c.Config.FieldCodecs
+	if fallback == nil && len(names) == 0 && len(codecs) == 0 && r.transform == nil {
+		head := &headWriter{}
+		if err = decodeJson(driver, io.TeeReader(resp.Body, head), data); err != nil {
+			// The decoder may have stopped early, read the rest of the snippet
+			io.CopyN(head, resp.Body, int64(contentTypeSnippetSize-len(head.b)))
+			if ctErr := checkContentType(resp, head.b); ctErr != nil {
+				err = ctErr
+			}
+		}
+		return &Response{Header: resp.Header}, err
+	}
+	b, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
 		return nil, err
 	}
-	if !validator(resp.StatusCode) {
-		err = &RequestError{}
-		readJson(resp.Body, &err)
+	if err = checkContentType(resp, b); err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
-	if data == nil {
-		return nil, nil
+	if len(names) > 0 {
+		if b, err = renameProperties(b, names); err != nil {
+			return nil, err
+		}
+	}
+	if len(codecs) > 0 {
+		if b, err = decodeFields(b, codecs); err != nil {
+			return nil, err
+		}
+	}
+	if r.transform != nil {
+		if b, err = transformDocuments(b, r.transform); err != nil {
+			return nil, err
+		}
+	}
+	if err = decodeJson(driver, bytes.NewReader(b), data); err != nil && fallback != nil {
+		err = fallback.Unmarshal(b, data)
+	}
+	return &Response{Header: resp.Header}, err
+}
+
+// Send a single attempt of the request, bounded by Config.RequestTimeout.
+// The returned cancel func releases the attempt once its response body is read.
+func (c *Client) send(r *Request, attempt int) (*http.Response, context.CancelFunc, error) {
+	req, cancel := r.Request, context.CancelFunc(func() {})
+	ctx, span := c.startAttemptSpan(r, attempt)
+	if timeout := c.Config.RequestTimeout; timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+	}
+	if ctx != r.Context() {
+		req = req.WithContext(ctx)
+	}
+	start := time.Now()
+	resp, err := c.httpClient().Do(req)
+	endAttemptSpan(span, resp, err)
+	r.recordAttempt(resp)
+	c.observeRequest(r, resp, err, time.Since(start))
+	c.observeThrottle(resp)
+	c.trackSession(r, resp)
+	return resp, cancel, err
+}
+
+// The http client sending the requests: Config.HTTPClient if it's set, the embedded one otherwise
+func (c *Client) httpClient() *http.Client {
+	if c.Config.HTTPClient != nil {
+		return c.Config.HTTPClient
+	}
+	return &c.Client
+}
+
+// Build the error of a failed response
+func newRequestError(resp *http.Response) *RequestError {
+	defer closeBody(resp.Body)
+	reqErr := &RequestError{StatusCode: resp.StatusCode}
+	reqErr.SubStatus, _ = strconv.Atoi(resp.Header.Get(HeaderSubStatus))
+	reqErr.ActivityID = resp.Header.Get(HeaderActivityID)
+	reqErr.ResourceQuota = parseResourceQuota(resp.Header.Get(HeaderResourceQuota))
+	reqErr.ResourceUsage = parseResourceQuota(resp.Header.Get(HeaderResourceUsage))
+	if ms, err := strconv.Atoi(resp.Header.Get(HeaderRetryAfter)); err == nil {
+		reqErr.RetryAfter = time.Duration(ms) * time.Millisecond
+	}
+	reqErr.body, _ = ioutil.ReadAll(resp.Body)
+	Serialization.Unmarshal(reqErr.body, reqErr)
+	return reqErr
+}
+
+// Check if the request was rejected because its date is too far from the server clock
+func isClockSkew(err *RequestError) bool {
+	return err.StatusCode == http.StatusUnauthorized &&
+		strings.Contains(strings.ToLower(err.Message), "not valid at the current time")
+}
+
+// Store the offset of the server clock given its Date header, reporting whether it's known
+func (c *Client) syncClock(date string) bool {
+	serverTime, err := http.ParseTime(date)
+	if err != nil {
+		return false
 	}
-	return &Response{resp.Header}, readJson(resp.Body, data)
+	c.clockSkew.Store(int64(time.Until(serverTime)))
+	return true
+}
+
+// The current time according to the server clock
+func (c *Client) now() time.Time {
+	return time.Now().Add(time.Duration(c.clockSkew.Load()))
+}
+
+// The most bytes drained from a response body before it's closed, a larger rest costs less to
+// throw away with its connection than to read
+const maxDrainBytes = 1 << 20
+
+// Drain and close a response body, so its keep-alive connection goes back to the pool. A body
+// closed before it's read to the end closes its connection.
+func closeBody(body io.ReadCloser) {
+	io.CopyN(ioutil.Discard, body, maxDrainBytes)
+	body.Close()
 }
 
 // Read json response to given interface(struct, map, ..)
 func readJson(reader io.Reader, data interface{}) error {
-	return Serialization.DecoderFactory(reader).Decode(&data)
+	return decodeJson(&Serialization, reader, data)
+}
+
+// Read json response to given interface with the given driver
+func decodeJson(driver *SerializationDriver, reader io.Reader, data interface{}) error {
+	return driver.DecoderFactory(reader).Decode(&data)
+}
+
+// Stringify a document body, writing its zero values according to Config.ZeroValues,
+// renaming the custom system property names back and encoding the fields of Config.FieldCodecs
+func (c *Client) encode(body interface{}) ([]byte, error) {
+	return encodeDocument(c.Config, body)
+}
+
+// Stringify a document body according to the config, see Client.encode
+func encodeDocument(config *Config, body interface{}) ([]byte, error) {
+	if config == nil {
+		return stringify(body)
+	}
+	data, err := stringify(applyValueEncoding(body, valueEncoding{config.ZeroValues, config.TimeFormat}))
+	if err != nil {
+		return nil, err
+	}
+	if names := config.SystemPropertyNames; len(names) > 0 {
+		if data, err = renameProperties(data, invertNames(names)); err != nil {
+			return nil, err
+		}
+	}
+	if codecs := config.FieldCodecs; len(codecs) > 0 {
+		return encodeFields(data, codecs)
+	}
+	return data, nil
 }
 
 // Stringify body data
@@ -1,11 +1,19 @@
 package documentdb
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -75,7 +83,7 @@ func TestRead(t *testing.T) {
 
 	// Second Call, when StatusCode != StatusOK
 	_, err = client.Read("/dbs/b7NCAA==/colls/Ad352/", &db)
-	assert.Equal(err.Error(), "500, DocumentDB error")
+	assert.Equal(err.Error(), "500, DocumentDB error (request id: "+s.Header.Get(HeaderClientRequestID)+")")
 }
 
 func TestQuery(t *testing.T) {
@@ -94,7 +102,7 @@ func TestQuery(t *testing.T) {
 
 	// Second Call, when StatusCode != StatusOK
 	_, err = client.Read("/dbs/b7NCAA==/colls/Ad352/", &db)
-	assert.Equal(err.Error(), "500, DocumentDB error")
+	assert.Equal(err.Error(), "500, DocumentDB error (request id: "+s.Header.Get(HeaderClientRequestID)+")")
 }
 
 func TestCreate(t *testing.T) {
@@ -121,7 +129,7 @@ func TestCreate(t *testing.T) {
 
 	// Last Call, when StatusCode != StatusOK && StatusCreated
 	_, err = client.Create("dbs", tDoc, &doc)
-	assert.Equal(err.Error(), "500, DocumentDB error")
+	assert.Equal(err.Error(), "500, DocumentDB error (request id: "+s.Header.Get(HeaderClientRequestID)+")")
 }
 
 func TestDelete(t *testing.T) {
@@ -138,7 +146,7 @@ func TestDelete(t *testing.T) {
 
 	// Second Call, when StatusCode != StatusOK
 	_, err = client.Delete("/dbs/b7NCAA==/colls/Ad352/")
-	assert.Equal(err.Error(), "500, DocumentDB error")
+	assert.Equal(err.Error(), "500, DocumentDB error (request id: "+s.Header.Get(HeaderClientRequestID)+")")
 }
 
 func TestReplace(t *testing.T) {
@@ -165,7 +173,34 @@ func TestReplace(t *testing.T) {
 
 	// Last Call, when StatusCode != StatusOK && StatusCreated
 	_, err = client.Replace("dbs", tDoc, &doc)
-	assert.Equal(err.Error(), "500, DocumentDB error")
+	assert.Equal(err.Error(), "500, DocumentDB error (request id: "+s.Header.Get(HeaderClientRequestID)+")")
+}
+
+func TestPatch(t *testing.T) {
+	assert := assert.New(t)
+	s := ServerFactory(`{"id": "9", "count": 3}`, 500)
+	s.SetStatus(http.StatusOK)
+	defer s.Close()
+	client := &Client{Url: s.URL, Config: NewConfig(&Key{Key: "YXJpZWwNCg=="})}
+
+	var doc struct {
+		Id    string `json:"id"`
+		Count int    `json:"count"`
+	}
+	_, err := client.Patch("dbs/db/colls/coll/docs/9", []PatchOperation{
+		{Op: PatchIncrement, Path: "/count", Value: 1},
+		{Op: PatchRemove, Path: "/draft"},
+	}, &doc)
+	assert.Nil(err, "err should be nil")
+	s.AssertHeaders(t, HeaderXDate, HeaderAuth, HeaderVersion)
+	assert.Equal(ContentTypeJSONPatch, s.Header.Get(HeaderContentType))
+	assert.JSONEq(`{"operations": [{"op": "incr", "path": "/count", "value": 1}, {"op": "remove", "path": "/draft"}]}`, s.Body)
+	assert.Equal(3, doc.Count, "Should fill the fields from response body")
+
+	// Last Call, when StatusCode != StatusOK
+	_, err = client.Patch("dbs/db/colls/coll/docs/9", []PatchOperation{{Op: PatchSet, Path: "/count", Value: 0}}, &doc)
+	assert.Equal(err.Error(), "500, DocumentDB error (request id: "+s.Header.Get(HeaderClientRequestID)+")")
+	assert.JSONEq(`{"operations": [{"op": "set", "path": "/count", "value": 0}]}`, s.Body, "Zero values should be sent")
 }
 
 func TestExecute(t *testing.T) {
@@ -192,5 +227,369 @@ func TestExecute(t *testing.T) {
 
 	// Last Call, when StatusCode != StatusOK && StatusCreated
 	_, err = client.Execute("dbs", tDoc, &doc)
-	assert.Equal(err.Error(), "500, DocumentDB error")
+	assert.Equal(err.Error(), "500, DocumentDB error (request id: "+s.Header.Get(HeaderClientRequestID)+")")
+}
+
+func TestRequestErrorRetryAfter(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(HeaderRetryAfter, "250")
+		http.Error(w, `{"code": "429", "message": "Request rate is large"}`, http.StatusTooManyRequests)
+	}))
+	defer s.Close()
+	client := &Client{Url: s.URL, Config: NewConfig(&Key{Key: "YXJpZWwNCg=="})}
+	client.Config.RetryOptions = RetryOptions{}
+
+	_, err := client.Read("/dbs/b7NTAS==/", &Database{})
+	reqErr, ok := err.(*RequestError)
+	if assert.True(t, ok, "should return a RequestError") {
+		assert.Equal(t, http.StatusTooManyRequests, reqErr.StatusCode)
+		assert.Equal(t, 250*time.Millisecond, reqErr.RetryAfter)
+	}
+}
+
+func TestSystemPropertyNames(t *testing.T) {
+	assert := assert.New(t)
+	s := ServerFactory(`{"id": "1", "_etag": "e", "_ts": 5}`, `{"Documents": [{"id": "2", "_etag": "f", "nested": {"_ts": 1}}]}`)
+	s.SetStatus(http.StatusCreated)
+	defer s.Close()
+	config := NewConfig(&Key{Key: "YXJpZWwNCg=="})
+	config.SystemPropertyNames = map[string]string{"_etag": "etag", "_ts": "timestamp"}
+	client := &Client{Url: s.URL, Config: config}
+
+	type Model struct {
+		Id        string `json:"id"`
+		Etag      string `json:"etag,omitempty"`
+		Timestamp int    `json:"timestamp,omitempty"`
+	}
+	var created Model
+	_, err := client.Create("dbs/db/colls/coll/docs/", &Model{Id: "1", Etag: "old"}, &created)
+	assert.Nil(err)
+	assert.JSONEq(`{"id": "1", "_etag": "old"}`, s.Body, "should rename the properties back on write")
+	assert.Equal(Model{Id: "1", Etag: "e", Timestamp: 5}, created)
+
+	s.SetStatus(http.StatusOK)
+	var feed struct {
+		Documents []map[string]interface{}
+	}
+	_, err = client.Read("dbs/db/colls/coll/docs/", &feed)
+	assert.Nil(err)
+	assert.Equal("f", feed.Documents[0]["etag"])
+	assert.Equal(map[string]interface{}{"_ts": float64(1)}, feed.Documents[0]["nested"], "should keep the nested keys")
+}
+
+func TestSystemPropertyNamesResources(t *testing.T) {
+	assert := assert.New(t)
+	s := ServerFactory(`{"id": "coll", "_self": "dbs/db/colls/coll/", "_etag": "e"}`)
+	defer s.Close()
+	config := NewConfig(&Key{Key: "YXJpZWwNCg=="})
+	config.SystemPropertyNames = map[string]string{"_self": "self", "_etag": "etag"}
+	client := &Client{Url: s.URL, Config: config}
+
+	var coll Collection
+	_, err := client.Read("dbs/db/colls/coll/", &coll)
+	assert.Nil(err)
+	assert.Equal("dbs/db/colls/coll/", coll.Self, "should keep the system properties of collections")
+	assert.Equal("e", coll.Etag)
+}
+
+func TestSystemPropertyNamesCollision(t *testing.T) {
+	assert := assert.New(t)
+	s := ServerFactory(`{"id": "1", "_etag": "e", "etag": "user"}`)
+	defer s.Close()
+	config := NewConfig(&Key{Key: "YXJpZWwNCg=="})
+	config.SystemPropertyNames = map[string]string{"_etag": "etag"}
+	client := &Client{Url: s.URL, Config: config}
+
+	var doc map[string]interface{}
+	_, err := client.Read("dbs/db/colls/coll/docs/1", &doc)
+	assert.EqualError(err, `property "etag" is set twice after renaming the system properties`)
+}
+
+func TestContentType(t *testing.T) {
+	assert := assert.New(t)
+	s := ServerFactory(`{}`, `{}`, `{}`, `{}`, `{}`, `{}`)
+	defer s.Close()
+	client := &Client{Url: s.URL, Config: NewConfig(&Key{Key: "YXJpZWwNCg=="})}
+
+	s.SetStatus(http.StatusOK)
+	client.Read("dbs/b7NTAS==/", &Database{})
+	assert.Equal("", s.Header.Get(HeaderContentType))
+
+	client.Query("dbs", NewQuery("SELECT * FROM ROOT r"), &Database{})
+	assert.Equal(ContentTypeQueryJSON, s.Header.Get(HeaderContentType))
+
+	client.Replace("dbs/b7NTAS==/", `{"id": "1"}`, &Database{})
+	assert.Equal(ContentTypeJSON, s.Header.Get(HeaderContentType))
+
+	client.Upsert("dbs", `{"id": "1"}`, &Database{})
+	assert.Equal(ContentTypeJSON, s.Header.Get(HeaderContentType))
+
+	s.SetStatus(http.StatusCreated)
+	client.Create("dbs", `{"id": "1"}`, &Database{})
+	assert.Equal(ContentTypeJSON, s.Header.Get(HeaderContentType))
+
+	client.Create("dbs", `{"id": "1"}`, &Database{}, ContentType("application/x-custom"))
+	assert.Equal([]string{"application/x-custom"}, s.Header[HeaderContentType])
+}
+
+func TestFallbackSerialization(t *testing.T) {
+	assert := assert.New(t)
+	s := ServerFactory(`{"id": "1", "age": "31"}`, `{"id": "1", "age": "31"}`)
+	defer s.Close()
+	config := NewConfig(&Key{Key: "YXJpZWwNCg=="})
+	client := &Client{Url: s.URL, Config: config}
+
+	type Model struct {
+		Id  string `json:"id"`
+		Age int    `json:"age"`
+	}
+	var doc Model
+	_, err := client.Read("dbs/db/colls/coll/docs/1", &doc)
+	assert.Error(err, "should fail with the strict driver")
+
+	config.FallbackSerialization = &LenientSerialization
+	doc = Model{}
+	_, err = client.Read("dbs/db/colls/coll/docs/1", &doc)
+	assert.Nil(err)
+	assert.Equal(Model{Id: "1", Age: 31}, doc)
+}
+
+func TestDecodeWith(t *testing.T) {
+	assert := assert.New(t)
+	s := ServerFactory(`{"id": "1", "age": "31"}`, `{"id": "1", "age": "31"}`, `{"id": "1", "age": "31"}`)
+	defer s.Close()
+	config := NewConfig(&Key{Key: "YXJpZWwNCg=="})
+	client := &Client{Url: s.URL, Config: config}
+
+	type Model struct {
+		Id  string `json:"id"`
+		Age int    `json:"age"`
+	}
+	var doc Model
+	_, err := client.Read("dbs/db/colls/coll/docs/1", &doc, DecodeWith(&LenientSerialization))
+	assert.Nil(err)
+	assert.Equal(Model{Id: "1", Age: 31}, doc)
+
+	// The driver of the call overrides the fallback of the client
+	config.FallbackSerialization = &LenientSerialization
+	_, err = client.Read("dbs/db/colls/coll/docs/1", &Model{}, DecodeWith(&DefaultSerialization))
+	assert.Error(err)
+	_, err = client.Read("dbs/db/colls/coll/docs/1", &Model{})
+	assert.Nil(err)
+}
+
+func TestClockSkew(t *testing.T) {
+	var calls, rejected int
+	serverTime := time.Now().Add(time.Hour)
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		date, _ := http.ParseTime(r.Header.Get(HeaderXDate))
+		if d := serverTime.Sub(date); d > 15*time.Minute || d < -15*time.Minute {
+			rejected++
+			w.Header().Set("Date", serverTime.UTC().Format(http.TimeFormat))
+			http.Error(w, `{"code": "Unauthorized", "message": "The authorization token is not valid at the current time."}`, http.StatusUnauthorized)
+			return
+		}
+		b, _ := ioutil.ReadAll(r.Body)
+		w.WriteHeader(http.StatusCreated)
+		w.Write(b)
+	}))
+	defer s.Close()
+	client := &Client{Url: s.URL, Config: NewConfig(&Key{Key: "YXJpZWwNCg=="})}
+
+	var doc Document
+	_, err := client.Create("dbs/db/colls/coll/docs/", `{"id": "1"}`, &doc)
+	assert.NoError(t, err)
+	assert.Equal(t, "1", doc.Id, "should send the body again on retry")
+	assert.Equal(t, 2, calls)
+
+	_, err = client.Create("dbs/db/colls/coll/docs/", `{"id": "2"}`, &doc)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, calls)
+	assert.Equal(t, 1, rejected, "should keep using the corrected clock")
+}
+
+func TestUnauthorizedIsNotRetried(t *testing.T) {
+	var calls int
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		http.Error(w, `{"code": "Unauthorized", "message": "The input authorization token can't serve the request."}`, http.StatusUnauthorized)
+	}))
+	defer s.Close()
+	client := &Client{Url: s.URL, Config: NewConfig(&Key{Key: "YXJpZWwNCg=="})}
+
+	_, err := client.Read("/dbs/b7NTAS==/", &Database{})
+	assert.Equal(t, http.StatusUnauthorized, err.(*RequestError).StatusCode)
+	assert.Equal(t, 1, calls)
+}
+
+func TestConcurrentRequestBodies(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var q Query
+		json.NewDecoder(r.Body).Decode(&q)
+		fmt.Fprintf(w, `{"id": %q}`, q.Query)
+	}))
+	defer s.Close()
+	client := &Client{Url: s.URL, Config: NewConfig(&Key{Key: "YXJpZWwNCg=="})}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			query := fmt.Sprintf("SELECT * FROM ROOT r WHERE r.n = %d", i)
+			var doc, read Document
+			_, err := client.Query("dbs/db/colls/coll/docs/", NewQuery(query), &doc)
+			assert.NoError(t, err)
+			assert.Equal(t, query, doc.Id, "should send the query it was given")
+			_, err = client.Read("dbs/db/colls/coll/docs/", &read)
+			assert.NoError(t, err)
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestWithMasterKey(t *testing.T) {
+	assert := assert.New(t)
+	defaultKey, callKey := &Key{Key: "YXJpZWwNCg=="}, &Key{Key: "c2Vjb25kYXJ5"}
+	var auths, dates []string
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auths, dates = append(auths, r.Header.Get(HeaderAuth)), append(dates, r.Header.Get(HeaderXDate))
+		if len(auths) == 2 {
+			http.Error(w, `{"code": "ServiceUnavailable"}`, http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprint(w, `{}`)
+	}))
+	defer s.Close()
+	config := NewConfig(defaultKey)
+	config.RetryOptions = RetryOptions{MaxRetries: 1, Jitter: JitterNone}
+	client := &Client{Url: s.URL, Config: config}
+	// The signature of a read of the document, for the given key and date
+	signature := func(key *Key, date string) string {
+		sign, _ := authorize(bytes.ToLower([]byte("GET\ndocs\n1\n"+date+"\n\n")), key)
+		return url.QueryEscape("type=master&ver=1.0&sig=" + sign)
+	}
+
+	_, err := client.Read("dbs/db/colls/coll/docs/1", nil)
+	assert.NoError(err)
+	// The call key signs the request and its retries
+	_, err = client.Read("dbs/db/colls/coll/docs/1", nil, WithMasterKey(callKey))
+	assert.NoError(err)
+	assert.Len(auths, 3)
+	assert.Equal(signature(defaultKey, dates[0]), auths[0])
+	assert.Equal(signature(callKey, dates[1]), auths[1])
+	assert.Equal(signature(callKey, dates[2]), auths[2])
+	assert.NotEqual(signature(defaultKey, dates[1]), auths[1])
+}
+
+func TestConnectionReuse(t *testing.T) {
+	assert := assert.New(t)
+	body := `{"id": "1", "data": "` + strings.Repeat("x", 512<<10) + `"}`
+	s := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/dbs/db/colls/coll/docs/missing" {
+			http.Error(w, body, http.StatusNotFound)
+			return
+		}
+		fmt.Fprint(w, body)
+	}))
+	var conns int
+	var mu sync.Mutex
+	s.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		mu.Lock()
+		defer mu.Unlock()
+		if state == http.StateNew {
+			conns++
+		}
+	}
+	s.Start()
+	defer s.Close()
+	client := &Client{Url: s.URL, Config: NewConfig(&Key{Key: "YXJpZWwNCg=="})}
+
+	for i := 0; i < 3; i++ {
+		_, err := client.Read("dbs/db/colls/coll/docs/1", nil)
+		assert.NoError(err)
+		_, err = client.Read("dbs/db/colls/coll/docs/missing", nil)
+		assert.Error(err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(1, conns, "should drain the response bodies and reuse the connection")
+}
+
+type RoundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f RoundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func TestHTTPClient(t *testing.T) {
+	assert := assert.New(t)
+	s := ServerFactory(`{"id": "1"}`, `{"id": "1"}`)
+	defer s.Close()
+	var calls int
+	httpClient := &http.Client{Transport: RoundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		calls++
+		return http.DefaultTransport.RoundTrip(r)
+	})}
+	c := New(s.URL, NewConfig(&Key{Key: "YXJpZWwNCg=="}).WithHTTPClient(httpClient))
+
+	err := c.ReadDocument("dbs/db/colls/coll/docs/1", &Document{})
+	assert.NoError(err)
+	assert.Equal(1, calls, "should send the requests with the given client")
+}
+
+func TestResourceToken(t *testing.T) {
+	assert := assert.New(t)
+	var auths []string
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auths = append(auths, r.Header.Get(HeaderAuth))
+		if len(auths) == 1 {
+			http.Error(w, `{"code": "ServiceUnavailable"}`, http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprint(w, `{}`)
+	}))
+	defer s.Close()
+	config := NewConfig(&Key{Key: "YXJpZWwNCg=="})
+	config.RetryOptions = RetryOptions{MaxRetries: 1, Jitter: JitterNone}
+	client := &Client{Url: s.URL, Config: config}
+
+	token := url.QueryEscape("type=resource&ver=1&sig=tenant-a")
+	_, err := client.Read("dbs/db/colls/coll/docs/1", nil, ResourceToken(token), WithMasterKey(&Key{Key: "c2Vjb25kYXJ5"}))
+	assert.NoError(err)
+	assert.Equal([]string{token, token}, auths, "The token should authorize the request and its retries")
+
+	_, err = client.Read("dbs/db/colls/coll/docs/1", nil, ResourceToken(""))
+	assert.NoError(err)
+	assert.Contains(auths[2], url.QueryEscape("type=master"))
+}
+
+func TestConditionalRequests(t *testing.T) {
+	assert := assert.New(t)
+	etag := `"1"`
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPut && r.Header.Get(HeaderIfMatch) != etag:
+			http.Error(w, `{"code": "PreconditionFailed", "message": "Operation cannot be performed"}`, http.StatusPreconditionFailed)
+		case r.Method == http.MethodGet && r.Header.Get(HeaderIfNonMatch) == etag:
+			w.WriteHeader(http.StatusNotModified)
+		default:
+			fmt.Fprintf(w, `{"id": "1", "_etag": %q}`, etag)
+		}
+	}))
+	defer s.Close()
+	client := &Client{Url: s.URL, Config: NewConfig(&Key{Key: "YXJpZWwNCg=="})}
+
+	_, err := client.Replace("dbs/db/colls/coll/docs/1", `{"id": "1"}`, nil, IfMatch(`"0"`))
+	assert.True(errors.Is(err, ErrPreconditionFailed))
+	_, err = client.Replace("dbs/db/colls/coll/docs/1", `{"id": "1"}`, nil, IfMatch(etag))
+	assert.NoError(err)
+
+	_, err = client.Read("dbs/db/colls/coll/docs/1", nil, IfNoneMatch(etag))
+	assert.True(errors.Is(err, ErrNotModified))
+	var doc Document
+	_, err = client.Read("dbs/db/colls/coll/docs/1", &doc, IfNoneMatch(`"0"`))
+	assert.NoError(err)
+	assert.Equal(etag, doc.Etag)
 }
@@ -0,0 +1,227 @@
+package documentdb
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+)
+
+// FieldCodec rewrites the stored form of a document field, e.g: to compress or encrypt it,
+// see Config.FieldCodecs. Implementations must be safe for concurrent use.
+type FieldCodec interface {
+	// Encode returns the stored form of the json value of the field
+	Encode(value []byte) ([]byte, error)
+	// Decode returns the json value of the field from its stored form
+	Decode(stored []byte) ([]byte, error)
+}
+
+// GzipCodec compresses the fields with gzip, e.g: large blob-like payloads
+var GzipCodec FieldCodec = gzipCodec{}
+
+type gzipCodec struct{}
+
+func (gzipCodec) Encode(value []byte) ([]byte, error) {
+	var b bytes.Buffer
+	w := gzip.NewWriter(&b)
+	if _, err := w.Write(value); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+func (gzipCodec) Decode(stored []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(stored))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return ioutil.ReadAll(r)
+}
+
+// ChainCodecs composes codecs: fields are encoded by every codec in order and decoded in reverse
+// order, e.g: ChainCodecs(GzipCodec, encryption) compresses the fields before encrypting them
+func ChainCodecs(codecs ...FieldCodec) FieldCodec {
+	return codecChain(codecs)
+}
+
+type codecChain []FieldCodec
+
+func (chain codecChain) Encode(value []byte) (_ []byte, err error) {
+	for _, codec := range chain {
+		if value, err = codec.Encode(value); err != nil {
+			return nil, err
+		}
+	}
+	return value, nil
+}
+
+func (chain codecChain) Decode(stored []byte) (_ []byte, err error) {
+	for i := len(chain) - 1; i >= 0; i-- {
+		if stored, err = chain[i].Decode(stored); err != nil {
+			return nil, err
+		}
+	}
+	return stored, nil
+}
+
+// ErrFieldCodecsUnsupported is returned by the writes that can't encode the fields of
+// Config.FieldCodecs, instead of storing them as is
+var ErrFieldCodecsUnsupported = errors.New("the write can't encode the fields of the field codecs")
+
+// Encode the fields of a document with their codecs, they're stored as base64 strings
+func encodeFields(doc []byte, codecs map[string]FieldCodec) ([]byte, error) {
+	for path, codec := range codecs {
+		var err error
+		doc, err = rewriteField(doc, splitPath(path), encodeField(path, codec))
+		if err != nil {
+			return nil, err
+		}
+	}
+	return doc, nil
+}
+
+// Encode the json value of a field to its stored form
+func encodeField(path string, codec FieldCodec) func(json.RawMessage) (json.RawMessage, error) {
+	return func(value json.RawMessage) (json.RawMessage, error) {
+		stored, err := codec.Encode(value)
+		if err != nil {
+			return nil, fmt.Errorf("encode field %s: %w", path, err)
+		}
+		return Serialization.Marshal(base64.StdEncoding.EncodeToString(stored))
+	}
+}
+
+// Encode the values written by patch operations: the value of an encoded field, or the encoded
+// fields of the object written. Operations inside an encoded field, and increments of an encoded
+// field, fail with ErrFieldCodecsUnsupported.
+func encodePatchOperations(operations []PatchOperation, codecs map[string]FieldCodec) ([]PatchOperation, error) {
+	if len(codecs) == 0 {
+		return operations, nil
+	}
+	encoded := make([]PatchOperation, len(operations))
+	for i, op := range operations {
+		encoded[i] = op
+		if op.Op == PatchRemove || op.Value == nil {
+			continue
+		}
+		target := splitPath(op.Path)
+		var (
+			codec  FieldCodec
+			nested = make(map[string]FieldCodec)
+		)
+		for path, c := range codecs {
+			field := splitPath(path)
+			switch {
+			case hasPathPrefix(target, field):
+				if len(target) > len(field) || op.Op == PatchIncrement {
+					return nil, fmt.Errorf("patch %s %s: %w", op.Op, op.Path, ErrFieldCodecsUnsupported)
+				}
+				codec = c
+			case hasPathPrefix(field, target):
+				nested["/"+strings.Join(field[len(target):], "/")] = c
+			}
+		}
+		if codec == nil && len(nested) == 0 {
+			continue
+		}
+		value, err := Serialization.Marshal(op.Value)
+		if err != nil {
+			return nil, err
+		}
+		if codec != nil {
+			value, err = encodeField(op.Path, codec)(value)
+		} else {
+			value, err = encodeFields(value, nested)
+		}
+		if err != nil {
+			return nil, err
+		}
+		encoded[i].Value = json.RawMessage(value)
+	}
+	return encoded, nil
+}
+
+// Check if a split document path starts with the given one
+func hasPathPrefix(path, prefix []string) bool {
+	if len(path) < len(prefix) {
+		return false
+	}
+	for i := range prefix {
+		if path[i] != prefix[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Decode the fields of a document, or of every document of a feed page, with their codecs
+func decodeFields(body []byte, codecs map[string]FieldCodec) ([]byte, error) {
+	decode := func(doc json.RawMessage) (json.RawMessage, error) {
+		for path, codec := range codecs {
+			var err error
+			doc, err = rewriteField(doc, splitPath(path), func(value json.RawMessage) (json.RawMessage, error) {
+				var encoded string
+				if err := Serialization.Unmarshal(value, &encoded); err != nil {
+					return nil, fmt.Errorf("decode field %s: %w", path, err)
+				}
+				stored, err := base64.StdEncoding.DecodeString(encoded)
+				if err != nil {
+					return nil, fmt.Errorf("decode field %s: %w", path, err)
+				}
+				if value, err = codec.Decode(stored); err != nil {
+					return nil, fmt.Errorf("decode field %s: %w", path, err)
+				}
+				return value, nil
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+		return doc, nil
+	}
+	var envelope map[string]json.RawMessage
+	if err := Serialization.Unmarshal(body, &envelope); err != nil {
+		return body, nil
+	}
+	if _, ok := envelope["Documents"]; ok {
+		return transformDocuments(body, decode)
+	}
+	return decode(body)
+}
+
+// Rewrite the value of the field at the given path of a json object, objects missing
+// the field(or holding null) are returned as is
+func rewriteField(obj []byte, path []string, rewrite func(json.RawMessage) (json.RawMessage, error)) ([]byte, error) {
+	var fields map[string]json.RawMessage
+	if err := Serialization.Unmarshal(obj, &fields); err != nil || fields == nil {
+		return obj, nil
+	}
+	value, ok := fields[path[0]]
+	if !ok || string(bytes.TrimSpace(value)) == "null" {
+		return obj, nil
+	}
+	var err error
+	if len(path) == 1 {
+		value, err = rewrite(value)
+	} else {
+		value, err = rewriteField(value, path[1:], rewrite)
+	}
+	if err != nil {
+		return nil, err
+	}
+	fields[path[0]] = value
+	return Serialization.Marshal(fields)
+}
+
+// Split a document path, e.g: "/payload/body" to ["payload", "body"]
+func splitPath(path string) []string {
+	return strings.Split(strings.Trim(path, "/"), "/")
+}
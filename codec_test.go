@@ -0,0 +1,174 @@
+package documentdb
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Reverses the stored bytes, standing in for an encryption codec
+type reverseCodec struct{}
+
+func (reverseCodec) Encode(value []byte) ([]byte, error)  { return reverse(value), nil }
+func (reverseCodec) Decode(stored []byte) ([]byte, error) { return reverse(stored), nil }
+
+func reverse(b []byte) []byte {
+	r := make([]byte, len(b))
+	for i := range b {
+		r[len(b)-1-i] = b[i]
+	}
+	return r
+}
+
+func TestFieldCodecs(t *testing.T) {
+	assert := assert.New(t)
+	var stored []byte
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			if r.Header.Get(HeaderIsQuery) != "" {
+				fmt.Fprintf(w, `{"Documents": [%s, {"id": "2"}], "_count": 2}`, stored)
+				return
+			}
+			stored, _ = ioutil.ReadAll(r.Body)
+			w.WriteHeader(http.StatusCreated)
+			w.Write(stored)
+		default:
+			w.Write(stored)
+		}
+	}))
+	defer s.Close()
+	config := NewConfig(&Key{Key: "YXJpZWwNCg=="})
+	config.FieldCodecs = map[string]FieldCodec{
+		"/payload":   GzipCodec,
+		"/meta/blob": ChainCodecs(GzipCodec, reverseCodec{}),
+	}
+	c := New(s.URL, config)
+
+	type Doc struct {
+		Document
+		Payload map[string]string `json:"payload"`
+		Meta    struct {
+			Blob string `json:"blob"`
+		} `json:"meta"`
+	}
+	doc := Doc{Document: Document{Resource: Resource{Id: "1"}}, Payload: map[string]string{"body": "hello"}}
+	doc.Meta.Blob = "world"
+	var created Doc
+	_, err := c.client.Create("dbs/db/colls/coll/docs/", &doc, &created)
+	assert.NoError(err)
+	assert.Equal(doc, created)
+
+	// The fields are stored encoded
+	var raw struct {
+		Payload string `json:"payload"`
+		Meta    struct {
+			Blob string `json:"blob"`
+		} `json:"meta"`
+	}
+	assert.NoError(json.Unmarshal(stored, &raw))
+	compressed, _ := base64.StdEncoding.DecodeString(raw.Payload)
+	payload, err := GzipCodec.Decode(compressed)
+	assert.NoError(err)
+	assert.JSONEq(`{"body": "hello"}`, string(payload))
+	blob, _ := base64.StdEncoding.DecodeString(raw.Meta.Blob)
+	blob, err = GzipCodec.Decode(reverse(blob))
+	assert.NoError(err)
+	assert.Equal(`"world"`, string(blob))
+
+	var read Doc
+	assert.NoError(c.ReadDocument("dbs/db/colls/coll/docs/1", &read))
+	assert.Equal(doc, read)
+
+	// Documents without the fields are read as is
+	var docs []Doc
+	_, err = c.QueryDocuments("dbs/db/colls/coll/", NewQuery("SELECT * FROM c"), &docs)
+	assert.NoError(err)
+	assert.Equal([]Doc{doc, {Document: Document{Resource: Resource{Id: "2"}}}}, docs)
+
+	// A field that isn't encoded fails the read
+	stored = []byte(`{"id": "3", "payload": "plain"}`)
+	err = c.ReadDocument("dbs/db/colls/coll/docs/3", &read)
+	assert.Error(err)
+	assert.Contains(err.Error(), "decode field /payload")
+}
+
+func TestFieldCodecsBatchAndPatch(t *testing.T) {
+	assert := assert.New(t)
+	var (
+		stored  = make(map[string]json.RawMessage)
+		patched []PatchOperation
+	)
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			var ops []BatchOperation
+			b, _ := ioutil.ReadAll(r.Body)
+			json.Unmarshal(b, &ops)
+			for _, op := range ops {
+				body, _ := json.Marshal(op.ResourceBody)
+				var doc Document
+				json.Unmarshal(body, &doc)
+				stored[doc.Id] = body
+			}
+			fmt.Fprint(w, `[{"statusCode": 200}]`)
+		case http.MethodPatch:
+			var req patchRequest
+			b, _ := ioutil.ReadAll(r.Body)
+			json.Unmarshal(b, &req)
+			patched = req.Operations
+			w.Write(stored["1"])
+		default:
+			w.Write(stored["1"])
+		}
+	}))
+	defer s.Close()
+	config := NewConfig(&Key{Key: "YXJpZWwNCg=="})
+	config.FieldCodecs = map[string]FieldCodec{"/ssn": reverseCodec{}, "/meta/blob": reverseCodec{}}
+	c := New(s.URL, config)
+
+	type Doc struct {
+		Document
+		Ssn  string `json:"ssn"`
+		Meta struct {
+			Blob string `json:"blob"`
+		} `json:"meta"`
+	}
+	doc := Doc{Document: Document{Resource: Resource{Id: "1"}}, Ssn: "123"}
+	doc.Meta.Blob = "secret"
+	_, _, err := c.ExecuteBatch("dbs/db/colls/coll/", "1", []BatchOperation{{OperationType: BatchUpsert, ResourceBody: doc}})
+	assert.NoError(err)
+	assert.NotContains(string(stored["1"]), "123")
+	assert.NotContains(string(stored["1"]), "secret")
+	var read Doc
+	assert.NoError(c.ReadDocument("dbs/db/colls/coll/docs/1", &read))
+	assert.Equal(doc, read)
+
+	// Patch values are encoded as a field, or as an object holding fields
+	_, err = c.PatchDocument("dbs/db/colls/coll/docs/1", []PatchOperation{
+		{Op: PatchSet, Path: "/ssn", Value: "456"},
+		{Op: PatchSet, Path: "/meta", Value: map[string]string{"blob": "other"}},
+		{Op: PatchSet, Path: "/name", Value: "plain"},
+	}, nil)
+	assert.NoError(err)
+	if assert.Len(patched, 3) {
+		assert.Equal(base64.StdEncoding.EncodeToString(reverse([]byte(`"456"`))), patched[0].Value)
+		assert.Equal(map[string]interface{}{"blob": base64.StdEncoding.EncodeToString(reverse([]byte(`"other"`)))}, patched[1].Value)
+		assert.Equal("plain", patched[2].Value)
+	}
+
+	// Writes that can't be encoded are refused
+	_, err = c.PatchDocument("dbs/db/colls/coll/docs/1", []PatchOperation{{Op: PatchSet, Path: "/meta/blob/x", Value: 1}}, nil)
+	assert.True(errors.Is(err, ErrFieldCodecsUnsupported))
+	_, err = c.PatchDocument("dbs/db/colls/coll/docs/1", []PatchOperation{{Op: PatchIncrement, Path: "/ssn", Value: 1}}, nil)
+	assert.True(errors.Is(err, ErrFieldCodecsUnsupported))
+	err = c.ExecuteStoredProcedure("dbs/db/colls/coll/sprocs/fn", []interface{}{"123"}, nil)
+	assert.True(errors.Is(err, ErrFieldCodecsUnsupported))
+}
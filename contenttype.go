@@ -0,0 +1,79 @@
+package documentdb
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// ErrUnexpectedContentType matches the errors of responses whose body isn't json, e.g: the html page of a
+// misconfigured gateway or firewall answering in place of the service. Check it with errors.Is, or get the
+// content type and the beginning of the body with errors.As and a *ContentTypeError.
+var ErrUnexpectedContentType = errors.New("unexpected response content type")
+
+// The length of the body snippet of a ContentTypeError
+const contentTypeSnippetSize = 256
+
+// ContentTypeError is the error of a response whose body isn't json
+type ContentTypeError struct {
+	ContentType string
+	StatusCode  int
+	// Snippet is the beginning of the response body
+	Snippet string
+}
+
+func (e *ContentTypeError) Error() string {
+	return fmt.Sprintf("%v %q in a %d response: %s", ErrUnexpectedContentType, e.ContentType, e.StatusCode, e.Snippet)
+}
+
+// Is reports whether the error matches ErrUnexpectedContentType
+func (e *ContentTypeError) Is(target error) bool {
+	return target == ErrUnexpectedContentType
+}
+
+// Return a ContentTypeError if the response has a content type other than json and its body(or the
+// beginning of it) doesn't look like json either, nil otherwise
+func checkContentType(resp *http.Response, body []byte) error {
+	contentType := resp.Header.Get(HeaderContentType)
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if mediaType == "" || strings.HasSuffix(mediaType, "json") || looksLikeJSON(body) {
+		return nil
+	}
+	if len(body) > contentTypeSnippetSize {
+		body = body[:contentTypeSnippetSize]
+	}
+	return &ContentTypeError{ContentType: contentType, StatusCode: resp.StatusCode, Snippet: string(body)}
+}
+
+// Check if a body starts like a json value, the gateway may send json with another content type
+func looksLikeJSON(body []byte) bool {
+	body = bytes.TrimSpace(body)
+	if len(body) == 0 {
+		return true
+	}
+	switch c := body[0]; {
+	case c == '{', c == '[', c == '"', c == '-', c >= '0' && c <= '9':
+		return true
+	default:
+		return bytes.HasPrefix(body, []byte("true")) || bytes.HasPrefix(body, []byte("false")) || bytes.HasPrefix(body, []byte("null"))
+	}
+}
+
+// headWriter keeps the beginning of what's written to it, to report the body of a response
+// that failed to decode
+type headWriter struct {
+	b []byte
+}
+
+func (w *headWriter) Write(p []byte) (int, error) {
+	if n := contentTypeSnippetSize - len(w.b); n > 0 {
+		if n > len(p) {
+			n = len(p)
+		}
+		w.b = append(w.b, p[:n]...)
+	}
+	return len(p), nil
+}
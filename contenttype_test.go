@@ -0,0 +1,47 @@
+package documentdb
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnexpectedContentType(t *testing.T) {
+	page := "<html><body>" + strings.Repeat("Access denied. ", 50) + "</body></html>"
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(HeaderContentType, "text/html; charset=utf-8")
+		w.Write([]byte(page))
+	}))
+	defer s.Close()
+
+	for name, fallback := range map[string]*SerializationDriver{"streamed": nil, "buffered": &LenientSerialization} {
+		config := NewConfig(&Key{Key: "YXJpZWwNCg=="})
+		config.FallbackSerialization = fallback
+		client := &Client{Url: s.URL, Config: config}
+		_, err := client.Read("dbs/db/colls/coll/docs/1", &Document{})
+		assert.True(t, errors.Is(err, ErrUnexpectedContentType), name)
+		var ctErr *ContentTypeError
+		if assert.True(t, errors.As(err, &ctErr), name) {
+			assert.Equal(t, "text/html; charset=utf-8", ctErr.ContentType, name)
+			assert.Equal(t, http.StatusOK, ctErr.StatusCode, name)
+			assert.Equal(t, page[:contentTypeSnippetSize], ctErr.Snippet, name)
+		}
+	}
+}
+
+func TestUnexpectedContentTypeJSONBody(t *testing.T) {
+	// A json body that doesn't fit the target fails with the decoding error, whatever its content type
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(HeaderContentType, "text/plain")
+		w.Write([]byte(`{"id": 1}`))
+	}))
+	defer s.Close()
+	client := &Client{Url: s.URL, Config: NewConfig(&Key{Key: "YXJpZWwNCg=="})}
+	_, err := client.Read("dbs/db/colls/coll/docs/1", &Document{})
+	assert.Error(t, err)
+	assert.False(t, errors.Is(err, ErrUnexpectedContentType))
+}
@@ -0,0 +1,144 @@
+package documentdb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+)
+
+// Reported by copyDocument for the documents the transform filtered out
+var errSkipped = errors.New("document skipped")
+
+// CopyError describes a document that couldn't be copied
+type CopyError struct {
+	// Index is the position of the document in the query results, starting from zero
+	Index int
+	// Id of the source document, empty if it couldn't be read
+	Id  string
+	Err error
+}
+
+// Implement Error function
+func (e CopyError) Error() string {
+	return fmt.Sprintf("document %d(%s): %v", e.Index, e.Id, e.Err)
+}
+
+// CopyResult holds the outcome of QueryInto
+type CopyResult struct {
+	Copied int
+	// Skipped counts the documents transform returned nil for
+	Skipped int
+	// Errors sorted by index
+	Errors []CopyError
+}
+
+// QueryInto streams the documents matching the query from the source collection, reshapes every one of them
+// with transform and upserts the result into the destination collection. A nil transform copies the documents
+// as they are. System properties(see SystemProperties) are removed before transform is called.
+// Returning a nil document from transform skips it.
+//
+// Documents are processed page by page, opts configures the upserts concurrency, their retries on throttling
+// and the progress callback like in ImportDocuments. callOpts apply to the query only, the upserts are sent
+// with the partition key of the destination collection.
+// Failing transforms and writes don't abort the copy, they are reported in CopyResult.Errors instead.
+// The returned error is set only if the query failed or ctx was cancelled.
+func (c *DocumentDB) QueryInto(ctx context.Context, src string, query *Query, dst string, transform func(json.RawMessage) (interface{}, error), opts *ImportOptions, callOpts ...CallOption) (*CopyResult, error) {
+	if opts == nil {
+		opts = &ImportOptions{}
+	}
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultBulkConcurrency
+	}
+	pkPath := ""
+	def, err := c.ReadPartitionKeyDefinition(dst)
+	switch {
+	case err == nil && len(def.Paths) > 0:
+		pkPath = def.Paths[0]
+	case err != nil && err != ErrNotPartitioned:
+		return nil, err
+	}
+
+	var (
+		mu     sync.Mutex
+		docs   []json.RawMessage
+		index  int
+		result = &CopyResult{}
+	)
+	iterator := NewIterator(c, NewDocumentIterator(src, query, &docs, append(lowPriority(callOpts), WithContext(ctx))...))
+	for ctx.Err() == nil && iterator.Next() {
+		runParallel(len(docs), concurrency, func(i int) {
+			id, err := c.copyDocument(ctx, dst, docs[i], pkPath, transform, opts)
+			mu.Lock()
+			defer mu.Unlock()
+			switch err {
+			case nil:
+				result.Copied++
+			case errSkipped:
+				result.Skipped++
+			default:
+				result.Errors = append(result.Errors, CopyError{Index: index + i, Id: id, Err: err})
+			}
+			if opts.Progress != nil {
+				opts.Progress(result.Copied, len(result.Errors))
+			}
+		})
+		index += len(docs)
+		docs = docs[:0]
+	}
+	sort.Slice(result.Errors, func(i, j int) bool {
+		return result.Errors[i].Index < result.Errors[j].Index
+	})
+
+	if err := iterator.Error(); err != nil {
+		return result, err
+	}
+	return result, ctx.Err()
+}
+
+// Transform a single document and upsert it into the destination collection
+func (c *DocumentDB) copyDocument(ctx context.Context, dst string, doc json.RawMessage, pkPath string, transform func(json.RawMessage) (interface{}, error), opts *ImportOptions) (id string, err error) {
+	var source struct {
+		Id string `json:"id"`
+	}
+	if err = Serialization.Unmarshal(doc, &source); err != nil {
+		return "", err
+	}
+	if doc, err = stripProperties(doc, SystemProperties); err != nil {
+		return source.Id, err
+	}
+	var out interface{} = doc
+	if transform != nil {
+		if out, err = transform(doc); err != nil {
+			return source.Id, err
+		}
+		if isNilValue(out) {
+			return source.Id, errSkipped
+		}
+	}
+	callOpts := throttledOptions(ctx, nil)
+	if pkPath != "" {
+		pk, err := ValidatePartitionKey(out, pkPath)
+		if err != nil {
+			return source.Id, err
+		}
+		callOpts = append(callOpts, PartitionKey(pk))
+	}
+	return source.Id, c.upsertThrottled(ctx, dst, out, opts, callOpts)
+}
+
+// Check if the value is nil, or holds a nil pointer, map or slice, e.g: (*T)(nil)
+func isNilValue(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	switch rv := reflect.ValueOf(v); rv.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Interface, reflect.Func, reflect.Chan:
+		return rv.IsNil()
+	}
+	return false
+}
@@ -0,0 +1,126 @@
+package documentdb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryInto(t *testing.T) {
+	upserted := map[string]string{}
+	s, c := BulkServerFactory(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/dbs/db/colls/src/docs/":
+			assert.Equal(t, "true", r.Header.Get(HeaderIsQuery))
+			if r.Header.Get(HeaderContinuation) == "" {
+				w.Header().Set(HeaderContinuation, "page2")
+				fmt.Fprint(w, `{"Documents": [{"id": "1", "city": "a", "_rid": "r1", "_etag": "e1"}, {"id": "2", "city": "b"}, {"id": "3", "city": "c"}]}`)
+			} else {
+				fmt.Fprint(w, `{"Documents": [{"id": "4", "city": "d"}, {"id": "5"}]}`)
+			}
+		case "/dbs/db/colls/dst/":
+			fmt.Fprint(w, `{"id": "dst", "partitionKey": {"paths": ["/city"], "kind": "Hash"}}`)
+		case "/dbs/db/colls/dst/docs/":
+			b, _ := ioutil.ReadAll(r.Body)
+			var doc map[string]interface{}
+			json.Unmarshal(b, &doc)
+			if doc["id"] == "3" {
+				http.Error(w, `{"code": "400", "message": "Bad request"}`, http.StatusBadRequest)
+				return
+			}
+			upserted[doc["id"].(string)] = string(b)
+			assert.Equal(t, fmt.Sprintf(`[%q]`, doc["city"]), r.Header.Get(HeaderPartitionKey))
+			w.Write(b)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	defer s.Close()
+
+	var progress int
+	result, err := c.QueryInto(context.Background(), "dbs/db/colls/src/", NewQuery("SELECT * FROM c"), "dbs/db/colls/dst/",
+		func(doc json.RawMessage) (interface{}, error) {
+			var m map[string]interface{}
+			json.Unmarshal(doc, &m)
+			switch m["id"] {
+			case "2":
+				return nil, errors.New("can't transform")
+			case "4":
+				return nil, nil
+			}
+			m["copied"] = true
+			return m, nil
+		},
+		&ImportOptions{Progress: func(copied, failed int) { progress = copied + failed }},
+		CrossPartition(),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, result.Copied)
+	assert.Equal(t, 1, result.Skipped)
+	assert.Equal(t, 4, progress)
+	assert.JSONEq(t, `{"id": "1", "city": "a", "copied": true}`, upserted["1"], "should strip the system properties")
+	if assert.Len(t, result.Errors, 3) {
+		assert.Equal(t, CopyError{Index: 1, Id: "2", Err: errors.New("can't transform")}, result.Errors[0])
+		assert.Equal(t, 2, result.Errors[1].Index)
+		assert.Equal(t, http.StatusBadRequest, result.Errors[1].Err.(*RequestError).StatusCode)
+		assert.Equal(t, "5", result.Errors[2].Id)
+		assert.EqualError(t, result.Errors[2].Err, "partition key /city is missing in document")
+	}
+}
+
+func TestQueryIntoSkipTypedNil(t *testing.T) {
+	upserts := 0
+	s, c := BulkServerFactory(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/dbs/db/colls/src/docs/":
+			fmt.Fprint(w, `{"Documents": [{"id": "1"}, {"id": "2"}, {"id": "3"}]}`)
+		case "/dbs/db/colls/dst/":
+			fmt.Fprint(w, `{"id": "dst"}`)
+		default:
+			upserts++
+			w.Write([]byte(`{}`))
+		}
+	})
+	defer s.Close()
+
+	type Doc struct {
+		Id string `json:"id"`
+	}
+	result, err := c.QueryInto(context.Background(), "dbs/db/colls/src/", NewQuery("SELECT * FROM c"), "dbs/db/colls/dst/",
+		func(doc json.RawMessage) (interface{}, error) {
+			var d Doc
+			json.Unmarshal(doc, &d)
+			switch d.Id {
+			case "1":
+				return (*Doc)(nil), nil
+			case "2":
+				return map[string]interface{}(nil), nil
+			}
+			return []byte(nil), nil
+		}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, result.Skipped)
+	assert.Empty(t, result.Errors)
+	assert.Equal(t, 0, upserts)
+}
+
+func TestQueryIntoQueryFailure(t *testing.T) {
+	s, c := BulkServerFactory(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/dbs/db/colls/dst/" {
+			fmt.Fprint(w, `{"id": "dst"}`)
+			return
+		}
+		http.Error(w, `{"code": "400", "message": "Syntax error"}`, http.StatusBadRequest)
+	})
+	defer s.Close()
+
+	result, err := c.QueryInto(context.Background(), "dbs/db/colls/src/", NewQuery("SELECT"), "dbs/db/colls/dst/", nil, nil)
+	assert.Equal(t, http.StatusBadRequest, err.(*RequestError).StatusCode)
+	assert.Equal(t, 0, result.Copied)
+}
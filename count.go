@@ -0,0 +1,40 @@
+package documentdb
+
+// CountProgress reports the progress of CountDocuments, after every page read
+type CountProgress struct {
+	// Count is the number of documents counted so far
+	Count int64
+	// Pages is the number of pages read so far, every partition key range returns at least one
+	Pages int
+	// RequestCharge is the request units consumed so far
+	RequestCharge float64
+}
+
+// CountDocuments counts the documents of a collection, partition key range by range, summing the
+// partial counts of every page as they're read: the memory used doesn't grow with the collection.
+// The query must select a COUNT value(e.g: "SELECT VALUE COUNT(1) FROM c WHERE c.active"), nil counts
+// all the documents. progress, if not nil, is called after every page.
+func (c *DocumentDB) CountDocuments(coll string, query *Query, progress func(CountProgress), opts ...CallOption) (int64, error) {
+	if query == nil {
+		query = NewQuery("SELECT VALUE COUNT(1) FROM c")
+	}
+	var (
+		counts []int64
+		state  CountProgress
+	)
+	it := NewPartitionKeyRangeIterator(c, coll, &counts, append(opts[:len(opts):len(opts)], CrossPartition())...).WithQuery(query)
+	for it.Next() {
+		for _, n := range counts {
+			state.Count += n
+		}
+		state.Pages++
+		state.RequestCharge += it.Response().RequestCharge()
+		if progress != nil {
+			progress(state)
+		}
+	}
+	if err := it.Error(); err != nil {
+		return 0, err
+	}
+	return state.Count, nil
+}
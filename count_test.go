@@ -0,0 +1,54 @@
+package documentdb
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCountDocuments(t *testing.T) {
+	assert := assert.New(t)
+	pages := map[string][]string{
+		"0": {`[40]`, `[2]`},
+		"1": {`[]`, `[100]`},
+	}
+	var queries []string
+	s, c := BulkServerFactory(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/pkranges/") {
+			fmt.Fprint(w, `{"PartitionKeyRanges": [{"id": "0"}, {"id": "1"}]}`)
+			return
+		}
+		var q Query
+		json.NewDecoder(r.Body).Decode(&q)
+		queries = append(queries, q.Query)
+		id := r.Header.Get(HeaderPartitionKeyRangeID)
+		page := 0
+		if r.Header.Get(HeaderContinuation) != "" {
+			page = 1
+		} else {
+			w.Header().Set(HeaderContinuation, "next-"+id)
+		}
+		w.Header().Set(HeaderRequestCharge, "2.5")
+		fmt.Fprintf(w, `{"Documents": %s}`, pages[id][page])
+	})
+	defer s.Close()
+
+	var progress []CountProgress
+	count, err := c.CountDocuments("dbs/db/colls/coll/", nil, func(p CountProgress) {
+		progress = append(progress, p)
+	})
+	assert.NoError(err)
+	assert.Equal(int64(142), count)
+	assert.Equal([]CountProgress{{40, 1, 2.5}, {42, 2, 5}, {42, 3, 7.5}, {142, 4, 10}}, progress)
+	assert.Equal("SELECT VALUE COUNT(1) FROM c", queries[0])
+
+	queries = nil
+	count, err = c.CountDocuments("dbs/db/colls/coll/", NewQuery("SELECT VALUE COUNT(1) FROM c WHERE c.active"), nil)
+	assert.NoError(err)
+	assert.Equal(int64(142), count)
+	assert.Equal("SELECT VALUE COUNT(1) FROM c WHERE c.active", queries[0])
+}
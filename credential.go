@@ -0,0 +1,69 @@
+package documentdb
+
+import (
+	"context"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// TokenCredential provides the Azure AD(Entra ID) tokens authorizing requests instead of a master key,
+// see Config.Credential. GetToken returns a token of the Cosmos DB scope(e.g: "https://<account>.documents.azure.com/.default")
+// and its expiry time. Implementations must be safe for concurrent use.
+type TokenCredential interface {
+	GetToken(ctx context.Context) (token string, expiresOn time.Time, err error)
+}
+
+// TokenRefreshMargin is how long before its expiry a cached token is replaced
+const TokenRefreshMargin = 5 * time.Minute
+
+// WithCredential authorizes the requests with the tokens of the given credential instead of a master key
+func (c *Config) WithCredential(credential TokenCredential) *Config {
+	c.Credential = credential
+	return c
+}
+
+// The token of Config.Credential, fetched again when it's about to expire
+type tokenCache struct {
+	mu        sync.Mutex
+	token     string
+	expiresOn time.Time
+}
+
+// Return the cached token, or a new one from the credential if it's missing or about to expire.
+// Concurrent callers wait for a single refresh.
+func (t *tokenCache) get(ctx context.Context, credential TokenCredential) (string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.token != "" && time.Until(t.expiresOn) > TokenRefreshMargin {
+		return t.token, nil
+	}
+	token, expiresOn, err := credential.GetToken(ctx)
+	if err != nil {
+		return "", err
+	}
+	t.token, t.expiresOn = token, expiresOn
+	return token, nil
+}
+
+// Sign the request for the server time: with its resource token if it has one(see ResourceToken),
+// with the token of Config.Credential if it's set, unless the request has a key of its own(see
+// WithMasterKey), with the master key otherwise
+func (c *Client) sign(r *Request) error {
+	if r.resourceToken != "" {
+		r.dateHeaders(c.now())
+		r.Header.Set(HeaderAuth, r.resourceToken)
+		return nil
+	}
+	credential := c.Config.Credential
+	if credential == nil || r.key != nil {
+		return r.defaultHeaders(c.masterKey(r), c.now())
+	}
+	token, err := c.tokens.get(r.Context(), credential)
+	if err != nil {
+		return err
+	}
+	r.dateHeaders(c.now())
+	r.Header.Set(HeaderAuth, url.QueryEscape("type=aad&ver=1.0&sig="+token))
+	return nil
+}
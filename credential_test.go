@@ -0,0 +1,62 @@
+package documentdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type CredentialStub struct {
+	calls     int
+	expiresIn time.Duration
+	err       error
+}
+
+func (c *CredentialStub) GetToken(ctx context.Context) (string, time.Time, error) {
+	c.calls++
+	return fmt.Sprintf("token%d", c.calls), time.Now().Add(c.expiresIn), c.err
+}
+
+func TestCredential(t *testing.T) {
+	assert := assert.New(t)
+	var auth []string
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = append(auth, r.Header.Get(HeaderAuth))
+		w.Write([]byte(`{"id": "1"}`))
+	}))
+	defer s.Close()
+	credential := &CredentialStub{expiresIn: time.Hour}
+	client := &Client{Url: s.URL, Config: NewConfig(nil).WithCredential(credential)}
+
+	for i := 0; i < 2; i++ {
+		_, err := client.Read("dbs/db/colls/coll/docs/1", &Document{})
+		assert.NoError(err)
+	}
+	assert.Equal(1, credential.calls, "should cache the token")
+	assert.Equal([]string{url.QueryEscape("type=aad&ver=1.0&sig=token1"), url.QueryEscape("type=aad&ver=1.0&sig=token1")}, auth)
+
+	// A token about to expire is replaced
+	credential.expiresIn = time.Minute
+	client.tokens = tokenCache{}
+	client.Read("dbs/db/colls/coll/docs/1", &Document{})
+	client.Read("dbs/db/colls/coll/docs/1", &Document{})
+	assert.Equal(3, credential.calls)
+	assert.Equal(url.QueryEscape("type=aad&ver=1.0&sig=token3"), auth[3])
+
+	// A key of the request takes precedence
+	_, err := client.Read("dbs/db/colls/coll/docs/1", &Document{}, WithMasterKey(NewKey("YXJpZWwNCg==")))
+	assert.NoError(err)
+	assert.Contains(auth[4], url.QueryEscape("type=master"))
+
+	credential.err = errors.New("no token")
+	client.tokens = tokenCache{}
+	_, err = client.Read("dbs/db/colls/coll/docs/1", &Document{})
+	assert.EqualError(err, "no token")
+}
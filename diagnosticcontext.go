@@ -0,0 +1,34 @@
+package documentdb
+
+import (
+	"fmt"
+	"sort"
+)
+
+// DiagnosticContext attaches application context to the call, e.g: {"tenantId": "a"}. The values are reported
+// in the RequestError of a failed call and in the RequestMetrics of its requests, the library doesn't
+// interpret them. The values of several DiagnosticContext options are merged.
+//
+// Keep them low cardinality if your MetricsObserver turns them into labels, and don't put secrets in them:
+// they end up in error messages and logs.
+func DiagnosticContext(values map[string]string) CallOption {
+	return func(r *Request) error {
+		if r.diagnosticContext == nil {
+			r.diagnosticContext = make(map[string]string, len(values))
+		}
+		for k, v := range values {
+			r.diagnosticContext[k] = v
+		}
+		return nil
+	}
+}
+
+// Format the diagnostic context as "key: value" pairs, sorted by key
+func formatDiagnosticContext(values map[string]string) []string {
+	pairs := make([]string, 0, len(values))
+	for k, v := range values {
+		pairs = append(pairs, fmt.Sprintf("%s: %s", k, v))
+	}
+	sort.Strings(pairs)
+	return pairs
+}
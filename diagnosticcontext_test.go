@@ -0,0 +1,37 @@
+package documentdb
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiagnosticContext(t *testing.T) {
+	assert := assert.New(t)
+	s := ServerFactory(`{}`, 500)
+	s.SetStatus(http.StatusOK)
+	defer s.Close()
+	observer := &ObserverRecorder{}
+	config := NewConfig(&Key{Key: "YXJpZWwNCg=="})
+	config.MetricsObserver = observer
+	config.RequestIDGenerator = func() string { return "r1" }
+	client := &Client{Url: s.URL, Config: config}
+	opts := []CallOption{
+		DiagnosticContext(map[string]string{"userId": "u1"}),
+		DiagnosticContext(map[string]string{"tenantId": "t1"}),
+	}
+
+	_, err := client.Read("dbs/db/colls/coll/docs/1", &Document{}, opts...)
+	assert.NoError(err)
+	_, err = client.Read("dbs/db/colls/coll/docs/1", &Document{}, opts...)
+	var reqErr *RequestError
+	if assert.True(errors.As(err, &reqErr)) {
+		assert.Equal(map[string]string{"userId": "u1", "tenantId": "t1"}, reqErr.DiagnosticContext)
+		assert.Equal("500, DocumentDB error (request id: r1, tenantId: t1, userId: u1)", err.Error())
+	}
+	if assert.Len(observer.Requests, 2) {
+		assert.Equal(map[string]string{"userId": "u1", "tenantId": "t1"}, observer.Requests[0].DiagnosticContext)
+	}
+}
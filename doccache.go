@@ -0,0 +1,141 @@
+package documentdb
+
+import (
+	"bytes"
+	"container/list"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DocumentCache caches documents with their etag, see Config.DocumentCache. Implementations must be
+// safe for concurrent use.
+type DocumentCache interface {
+	// Get returns the cached document of the key, with its etag
+	Get(key string) (doc []byte, etag string, ok bool)
+	Set(key string, doc []byte, etag string)
+	Delete(key string)
+}
+
+// MemoryDocumentCache is an in-memory DocumentCache, it keeps the most recently used documents
+// up to a size, and drops them after a TTL
+type MemoryDocumentCache struct {
+	size    int
+	ttl     time.Duration
+	mu      sync.Mutex
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+type documentCacheEntry struct {
+	key     string
+	doc     []byte
+	etag    string
+	expires time.Time
+}
+
+// NewMemoryDocumentCache creates an in-memory document cache holding up to size documents for ttl.
+// A zero size or ttl doesn't limit the size or the lifetime of the entries.
+func NewMemoryDocumentCache(size int, ttl time.Duration) *MemoryDocumentCache {
+	return &MemoryDocumentCache{
+		size:    size,
+		ttl:     ttl,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+// Get returns the cached document of the key if it didn't expire
+func (m *MemoryDocumentCache) Get(key string) ([]byte, string, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	el, ok := m.entries[key]
+	if !ok {
+		return nil, "", false
+	}
+	e := el.Value.(*documentCacheEntry)
+	if m.ttl > 0 && time.Now().After(e.expires) {
+		m.order.Remove(el)
+		delete(m.entries, key)
+		return nil, "", false
+	}
+	m.order.MoveToFront(el)
+	return e.doc, e.etag, true
+}
+
+// Set caches the document of the key, dropping the least recently used one when the cache is full
+func (m *MemoryDocumentCache) Set(key string, doc []byte, etag string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e := &documentCacheEntry{key: key, doc: doc, etag: etag, expires: time.Now().Add(m.ttl)}
+	if el, ok := m.entries[key]; ok {
+		el.Value = e
+		m.order.MoveToFront(el)
+		return
+	}
+	m.entries[key] = m.order.PushFront(e)
+	if m.size > 0 && m.order.Len() > m.size {
+		oldest := m.order.Back()
+		m.order.Remove(oldest)
+		delete(m.entries, oldest.Value.(*documentCacheEntry).key)
+	}
+}
+
+// Delete drops the cached document of the key
+func (m *MemoryDocumentCache) Delete(key string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if el, ok := m.entries[key]; ok {
+		m.order.Remove(el)
+		delete(m.entries, key)
+	}
+}
+
+// Read a document through Config.DocumentCache: the read is conditional on the etag of the cached
+// document, which is returned when the server answers it didn't change(304)
+func (c *Client) readCached(r *Request, ret interface{}) (*Response, error) {
+	cache := c.Config.DocumentCache
+	// The same link names a document per logical partition
+	key := r.URL.Path + "\n" + strings.Join(r.Header[HeaderPartitionKey], "")
+	cached, etag, ok := cache.Get(key)
+	validator := expectStatusCode(http.StatusOK)
+	if ok {
+		r.Header.Set(HeaderIfNonMatch, etag)
+		validator = func(statusCode int) bool {
+			return statusCode == http.StatusOK || statusCode == http.StatusNotModified
+		}
+	}
+	var doc json.RawMessage
+	resp, err := c.do(r, validator, &doc)
+	if err != nil {
+		if reqErr, isReqErr := err.(*RequestError); isReqErr && reqErr.StatusCode == http.StatusNotFound {
+			cache.Delete(key)
+		}
+		return nil, err
+	}
+	// A 304 response has no body
+	if doc == nil {
+		doc = cached
+	} else if etag = resp.Header.Get(HeaderETag); etag != "" || documentETag(doc, &etag) {
+		cache.Set(key, doc, etag)
+	}
+	return resp, decodeJson(r.serialization(), bytes.NewReader(doc), ret)
+}
+
+// Read the _etag property of a document, reporting whether it has one
+func documentETag(doc []byte, etag *string) bool {
+	var props struct {
+		Etag string `json:"_etag"`
+	}
+	Serialization.Unmarshal(doc, &props)
+	*etag = props.Etag
+	return *etag != ""
+}
+
+// Check whether a link is the link of a single document, e.g: "dbs/db/colls/coll/docs/1"
+func isDocumentLink(link string) bool {
+	parts := strings.Split(strings.Trim(link, "/"), "/")
+	return len(parts) >= 2 && len(parts)%2 == 0 && parts[len(parts)-2] == "docs"
+}
@@ -0,0 +1,87 @@
+package documentdb
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDocumentCache(t *testing.T) {
+	assert := assert.New(t)
+	etag, notModified := `"1"`, 0
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/dbs/db/colls/coll/docs/missing" {
+			http.Error(w, `{"code": "NotFound"}`, http.StatusNotFound)
+			return
+		}
+		if r.Header.Get(HeaderIfNonMatch) == etag {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set(HeaderETag, etag)
+		fmt.Fprintf(w, `{"id": "1", "_etag": %q, "name": "v%s"}`, etag, etag[1:2])
+	}))
+	defer s.Close()
+	config := NewConfig(&Key{Key: "YXJpZWwNCg=="})
+	config.DocumentCache = NewMemoryDocumentCache(10, time.Minute)
+	c := New(s.URL, config)
+
+	type Doc struct {
+		Document
+		Name string `json:"name"`
+	}
+	for i := 0; i < 2; i++ {
+		var doc Doc
+		assert.NoError(c.ReadDocument("dbs/db/colls/coll/docs/1", &doc, PartitionKey("a")))
+		assert.Equal("v1", doc.Name)
+	}
+	assert.Equal(1, notModified, "should return the cached document when it didn't change")
+
+	// A changed document replaces the cached one
+	etag = `"2"`
+	var doc Doc
+	assert.NoError(c.ReadDocument("dbs/db/colls/coll/docs/1", &doc, PartitionKey("a")))
+	assert.Equal("v2", doc.Name)
+	_, cachedEtag, _ := config.DocumentCache.Get("/dbs/db/colls/coll/docs/1\n[\"a\"]")
+	assert.Equal(`"2"`, cachedEtag)
+
+	// Documents of another partition are cached apart
+	notModified = 0
+	assert.NoError(c.ReadDocument("dbs/db/colls/coll/docs/1", &doc, PartitionKey("b")))
+	assert.Equal(0, notModified)
+
+	// A conditional read of the caller isn't served from the cache
+	err := c.ReadDocument("dbs/db/colls/coll/docs/1", &doc, PartitionKey("a"), IfNoneMatch(etag))
+	assert.True(errors.Is(err, ErrNotModified))
+
+	assert.True(errors.Is(c.ReadDocument("dbs/db/colls/coll/docs/missing", &doc), ErrNotFound))
+}
+
+func TestMemoryDocumentCache(t *testing.T) {
+	assert := assert.New(t)
+	cache := NewMemoryDocumentCache(2, time.Minute)
+	cache.Set("a", []byte(`{}`), "1")
+	cache.Set("b", []byte(`{}`), "1")
+	cache.Get("a")
+	cache.Set("c", []byte(`{}`), "1")
+	_, _, ok := cache.Get("b")
+	assert.False(ok, "should drop the least recently used document")
+	_, etag, ok := cache.Get("a")
+	assert.True(ok)
+	assert.Equal("1", etag)
+	cache.Delete("a")
+	_, _, ok = cache.Get("a")
+	assert.False(ok)
+
+	cache = NewMemoryDocumentCache(0, time.Nanosecond)
+	cache.Set("a", []byte(`{}`), "1")
+	time.Sleep(time.Millisecond)
+	_, _, ok = cache.Get("a")
+	assert.False(ok, "should drop expired documents")
+}
@@ -9,9 +9,12 @@ package documentdb
 
 import (
 	"bytes"
+	"encoding/json"
+	"fmt"
 	"net/http"
 	"reflect"
 	"sync"
+	"time"
 )
 
 var buffers = &sync.Pool{
@@ -26,17 +29,85 @@ type IdentificationHydrator func(config *Config, doc interface{})
 
 // DefaultIdentificationHydrator fills Id
 func DefaultIdentificationHydrator(config *Config, doc interface{}) {
-	id := reflect.ValueOf(doc).Elem().FieldByName(config.IdentificationPropertyName)
+	v := reflect.ValueOf(doc)
+	// Raw json bodies and maps are sent as is
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return
+	}
+	id := v.Elem().FieldByName(config.IdentificationPropertyName)
 	if id.IsValid() && id.String() == "" {
 		id.SetString(uuid())
 	}
 }
 
 type Config struct {
-	MasterKey                  *Key
-	Client                     http.Client
+	MasterKey *Key
+	// Credential, if set, authorizes the requests with Azure AD(Entra ID) tokens instead of MasterKey,
+	// see WithCredential. Tokens are cached until TokenRefreshMargin before their expiry.
+	Credential TokenCredential
+	// Client is copied into the client created by New, HTTPClient overrides it
+	Client http.Client
+	// HTTPClient, if set, sends the requests instead of Client. Share it to reuse its connection
+	// pool, or set its Transport to tune the pool(e.g: MaxIdleConnsPerHost) or instrument the requests.
+	HTTPClient *http.Client
+	// ConnectionOptions tunes the connection pool of the transport New builds for Client, when
+	// Client has no Transport and HTTPClient isn't set. The zero value keeps http.DefaultTransport.
+	ConnectionOptions          ConnectionOptions
 	IdentificationHydrator     IdentificationHydrator
 	IdentificationPropertyName string
+	// SystemPropertyNames maps the system properties(e.g: "_etag") to the json names used
+	// by your models(e.g: "etag"). Responses are renamed before they are decoded, and
+	// written documents are renamed back. Only the top-level properties of the documents
+	// are renamed, the other resources(e.g: collections) keep the standard names.
+	// Leave empty to use the standard names.
+	SystemPropertyNames map[string]string
+	// MetadataCache caches the partitioning metadata of collections, set it to nil to disable caching
+	MetadataCache MetadataCache
+	// UseNumber decodes numbers as json.Number in generic results(e.g: ReadMap) to preserve their precision
+	UseNumber bool
+	// MetricsObserver, if set, is notified of every request and retry
+	MetricsObserver MetricsObserver
+	// FallbackSerialization, if set, is used to decode responses the Serialization driver failed
+	// to decode, e.g: LenientSerialization to read documents with a slightly different schema
+	FallbackSerialization *SerializationDriver
+	// RetryOptions configures the retries of transient failures, the zero value disables them
+	RetryOptions RetryOptions
+	// RequestTimeout, if set, bounds every attempt of a request on its own. Unlike a context
+	// deadline it applies to each retry afresh, so a slow attempt is abandoned and retried
+	// while the whole operation may take longer.
+	RequestTimeout time.Duration
+	// ZeroValues defines how the zero-value fields of written documents are serialized, it
+	// decides whether they are undefined, null or regular values for queries and TTL
+	ZeroValues ZeroValues
+	// TimeFormat defines how the Time values of written documents and query parameters are
+	// serialized, RFC3339 strings by default
+	TimeFormat TimeFormat
+	// ThrottleWindow is the period ThrottleRatio is computed over, DefaultThrottleWindow if zero
+	ThrottleWindow time.Duration
+	// Logger receives the diagnostic messages of the client: the attempts of every request
+	// at debug level, clock synchronizations at info level and retries at warn level
+	Logger Logger
+	// RequestIDGenerator returns the client request id of every call, reported in metrics,
+	// log messages and errors. The retries of a call share its id.
+	RequestIDGenerator RequestIDGenerator
+	// FieldCodecs rewrites the stored form of document fields by path(e.g: "/payload"), like
+	// GzipCodec to compress large fields. The fields are encoded in the documents written with
+	// Create, Upsert, Replace, ExecuteBatch and PatchDocument, and decoded in the documents read.
+	// Encoded fields are stored as base64 strings, so queries can't filter on their values. The
+	// writes that can't encode them fail with ErrFieldCodecsUnsupported, e.g: stored procedure
+	// arguments or patching inside an encoded field.
+	FieldCodecs map[string]FieldCodec
+	// DocumentCache, if set, caches the documents read by link(e.g: ReadDocument) with their etag. The
+	// following reads of a document are conditional(If-None-Match), and return the cached document when
+	// it didn't change: a 304 response costs less request units and transfers no body. Reads are
+	// always checked with the server, so the cache never serves a stale document.
+	DocumentCache DocumentCache
+	// Tracer, if set, traces every call with a span, and every http attempt with a child span
+	Tracer Tracer
+	// AutoTrackSessionToken makes the client keep the latest session token of every collection, and
+	// send it with the following requests to the collection, so reads see the writes of the client
+	// under Session consistency. A SessionToken option overrides it.
+	AutoTrackSessionToken bool
 }
 
 func NewConfig(key *Key) *Config {
@@ -44,6 +115,11 @@ func NewConfig(key *Key) *Config {
 		MasterKey:                  key,
 		IdentificationHydrator:     DefaultIdentificationHydrator,
 		IdentificationPropertyName: "Id",
+		MetadataCache:              NewMemoryMetadataCache(DefaultMetadataCacheTTL),
+		RetryOptions:               DefaultRetryOptions,
+		Logger:                     NopLogger,
+		RequestIDGenerator:         DefaultRequestIDGenerator,
+		ConnectionOptions:          DefaultConnectionOptions,
 	}
 }
 
@@ -53,6 +129,12 @@ func (c *Config) WithClient(client http.Client) *Config {
 	return c
 }
 
+// WithHTTPClient makes the documentdb client send its requests with the given http client, see Config.HTTPClient
+func (c *Config) WithHTTPClient(client *http.Client) *Config {
+	c.HTTPClient = client
+	return c
+}
+
 type DocumentDB struct {
 	client Clienter
 	config *Config
@@ -63,6 +145,9 @@ func New(url string, config *Config) *DocumentDB {
 	client := &Client{
 		Client: config.Client,
 	}
+	if config.HTTPClient == nil && client.Transport == nil && config.ConnectionOptions != (ConnectionOptions{}) {
+		client.Transport = newTransport(config.ConnectionOptions)
+	}
 	client.Url = url
 	client.Config = config
 	return &DocumentDB{client: client, config: config}
@@ -87,12 +172,44 @@ func (c *DocumentDB) ReadCollection(link string, opts ...CallOption) (coll *Coll
 	return
 }
 
+// Read the index transformation progress(0-100) of a collection by self link.
+// After replacing the indexing policy, the index is rebuilt asynchronously
+// and new indexes can be relied on only once it reaches 100.
+func (c *DocumentDB) ReadIndexTransformationProgress(link string, opts ...CallOption) (int, error) {
+	var coll *Collection
+	resp, err := c.client.Read(link, &coll, opts...)
+	if err != nil {
+		return 0, err
+	}
+	return resp.IndexTransformationProgress(), nil
+}
+
 // Read document by self link
 func (c *DocumentDB) ReadDocument(link string, doc interface{}, opts ...CallOption) (err error) {
 	_, err = c.client.Read(link, &doc, opts...)
 	return
 }
 
+// Read document by self link as a generic map, for callers that don't know its schema.
+// Numbers are decoded as json.Number when Config.UseNumber is set.
+func (c *DocumentDB) ReadMap(link string, opts ...CallOption) (doc map[string]interface{}, r *Response, err error) {
+	var raw json.RawMessage
+	if r, err = c.client.Read(link, &raw, opts...); err != nil {
+		return nil, nil, err
+	}
+	if c.config != nil && c.config.UseNumber {
+		decoder := json.NewDecoder(bytes.NewReader(raw))
+		decoder.UseNumber()
+		err = decoder.Decode(&doc)
+	} else {
+		err = Serialization.Unmarshal(raw, &doc)
+	}
+	if err != nil {
+		return nil, r, err
+	}
+	return doc, r, nil
+}
+
 // Read sporc by self link
 func (c *DocumentDB) ReadStoredProcedure(link string, opts ...CallOption) (sproc *Sproc, err error) {
 	_, err = c.client.Read(link, &sproc, opts...)
@@ -208,6 +325,7 @@ func (c *DocumentDB) QueryUserDefinedFunctions(coll string, query *Query, opts .
 // Read all documents in a collection that satisfy a query
 func (c *DocumentDB) QueryDocuments(coll string, query *Query, docs interface{}, opts ...CallOption) (response *Response, err error) {
 	data := struct {
+		Rid       string      `json:"_rid,omitempty"`
 		Documents interface{} `json:"Documents,omitempty"`
 		Count     int         `json:"_count,omitempty"`
 	}{Documents: docs}
@@ -216,9 +334,27 @@ func (c *DocumentDB) QueryDocuments(coll string, query *Query, docs interface{},
 	} else {
 		response, err = c.client.Read(coll+"docs/", &data, opts...)
 	}
+	if err == nil && response != nil {
+		response.Feed = &FeedMetadata{Rid: data.Rid, Count: data.Count}
+	}
 	return
 }
 
+// Query documents and return the undecoded page body(including the "Documents" envelope)
+// along with the continuation token of the next page, which is empty on the last page.
+// It's meant for callers that do their own decoding or streaming of query results.
+func (c *DocumentDB) QueryRaw(coll string, query *Query, opts ...CallOption) (raw json.RawMessage, continuation string, r *Response, err error) {
+	if query != nil {
+		r, err = c.client.Query(coll+"docs/", query, &raw, opts...)
+	} else {
+		r, err = c.client.Read(coll+"docs/", &raw, opts...)
+	}
+	if err != nil {
+		return nil, "", nil, err
+	}
+	return raw, r.Continuation(), r, nil
+}
+
 // Read collection's partition ranges
 func (c *DocumentDB) QueryPartitionKeyRanges(coll string, query *Query, opts ...CallOption) (ranges []PartitionKeyRange, err error) {
 	data := queryPartitionKeyRangesRequest{}
@@ -244,6 +380,9 @@ func (c *DocumentDB) CreateDatabase(body interface{}, opts ...CallOption) (db *D
 
 // Create collection
 func (c *DocumentDB) CreateCollection(db string, body interface{}, opts ...CallOption) (coll *Collection, err error) {
+	if err = validateCollection(body); err != nil {
+		return nil, err
+	}
 	_, err = c.client.Create(db+"colls/", body, &coll, opts...)
 	if err != nil {
 		return nil, err
@@ -285,6 +424,15 @@ func (c *DocumentDB) UpsertDocument(coll string, doc interface{}, opts ...CallOp
 	return c.client.Upsert(coll+"docs/", doc, &doc, opts...)
 }
 
+// Upsert document and decode the stored version returned by the server(with the updated
+// "_etag" and "_ts") into ret, so no follow-up read is needed
+func (c *DocumentDB) UpsertAndRead(coll string, doc, ret interface{}, opts ...CallOption) (*Response, error) {
+	if c.config != nil && c.config.IdentificationHydrator != nil {
+		c.config.IdentificationHydrator(c.config, doc)
+	}
+	return c.client.Upsert(coll+"docs/", doc, ret, opts...)
+}
+
 // TODO: DRY, but the sdk want that[mm.. maybe just client.Delete(self_link)]
 // Delete database
 func (c *DocumentDB) DeleteDatabase(link string, opts ...CallOption) (*Response, error) {
@@ -311,6 +459,19 @@ func (c *DocumentDB) DeleteUserDefinedFunction(link string, opts ...CallOption)
 	return c.client.Delete(link, opts...)
 }
 
+// Replace collection, e.g: to update its indexing policy.
+// The partition key and geospatial type can't be changed once the collection is created.
+func (c *DocumentDB) ReplaceCollection(link string, body interface{}, opts ...CallOption) (coll *Collection, err error) {
+	if err = validateCollection(body); err != nil {
+		return nil, err
+	}
+	_, err = c.client.Replace(link, body, &coll, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return
+}
+
 // Replace database
 func (c *DocumentDB) ReplaceDatabase(link string, body interface{}, opts ...CallOption) (db *Database, err error) {
 	_, err = c.client.Replace(link, body, &db)
@@ -343,8 +504,38 @@ func (c *DocumentDB) ReplaceUserDefinedFunction(link string, body interface{}, o
 	return
 }
 
-// Execute stored procedure
+// Execute stored procedure by self link. The arguments are sent as the json array the stored
+// procedure expects: a slice or an array is the argument list, nil is no arguments and any other
+// value is the single argument. A string or []byte is sent as the raw body. Partitioned stored
+// procedures run in the partition of the PartitionKey option.
+// The arguments can't be encoded with Config.FieldCodecs, a client with field codecs executes
+// stored procedures without arguments only, otherwise it fails with ErrFieldCodecsUnsupported.
 func (c *DocumentDB) ExecuteStoredProcedure(link string, params, body interface{}, opts ...CallOption) (err error) {
-	_, err = c.client.Execute(link, params, &body, opts...)
+	args := sprocArgs(params)
+	if c.config != nil && len(c.config.FieldCodecs) > 0 {
+		data, err := stringify(args)
+		if err != nil {
+			return err
+		}
+		if string(bytes.TrimSpace(data)) != "[]" {
+			return fmt.Errorf("execute stored procedure %s: %w", link, ErrFieldCodecsUnsupported)
+		}
+	}
+	_, err = c.client.Execute(link, args, &body, opts...)
 	return
 }
+
+// The argument list of a stored procedure execution
+func sprocArgs(params interface{}) interface{} {
+	switch params.(type) {
+	case nil:
+		return []interface{}{}
+	case string, []byte:
+		return params
+	}
+	switch reflect.ValueOf(params).Kind() {
+	case reflect.Slice, reflect.Array:
+		return params
+	}
+	return []interface{}{params}
+}
@@ -1,7 +1,11 @@
 package documentdb
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -51,6 +55,11 @@ func (c *ClientStub) Execute(link string, body, ret interface{}, opts ...CallOpt
 	return nil, nil
 }
 
+func (c *ClientStub) Patch(link string, operations []PatchOperation, ret interface{}, opts ...CallOption) (*Response, error) {
+	c.Called(link, operations)
+	return nil, nil
+}
+
 var defaultConfig = &Config{
 	IdentificationHydrator:     DefaultIdentificationHydrator,
 	IdentificationPropertyName: "Id",
@@ -304,6 +313,15 @@ func TestReplaceDocument(t *testing.T) {
 	client.AssertCalled(t, "Replace", "doc_link", "{}")
 }
 
+func TestPatchDocument(t *testing.T) {
+	client := &ClientStub{}
+	c := &DocumentDB{client, nil}
+	ops := []PatchOperation{{Op: PatchSet, Path: "/name", Value: "x"}}
+	client.On("Patch", "doc_link", ops).Return(nil)
+	c.PatchDocument("doc_link", ops, nil)
+	client.AssertCalled(t, "Patch", "doc_link", ops)
+}
+
 func TestReplaceStoredProcedure(t *testing.T) {
 	client := &ClientStub{}
 	c := &DocumentDB{client, nil}
@@ -328,6 +346,19 @@ func TestExecuteStoredProcedure(t *testing.T) {
 	client.AssertCalled(t, "Execute", "sproc_link", "{}")
 }
 
+func TestExecuteStoredProcedureArgs(t *testing.T) {
+	client := &ClientStub{}
+	c := &DocumentDB{client, nil}
+	item := map[string]int{"quantity": 1}
+	client.On("Execute", "sproc_link", mock.Anything).Return(nil)
+	c.ExecuteStoredProcedure("sproc_link", nil, nil)
+	client.AssertCalled(t, "Execute", "sproc_link", []interface{}{})
+	c.ExecuteStoredProcedure("sproc_link", []interface{}{"sku", 2}, nil, PartitionKey("sku"))
+	client.AssertCalled(t, "Execute", "sproc_link", []interface{}{"sku", 2})
+	c.ExecuteStoredProcedure("sproc_link", item, nil)
+	client.AssertCalled(t, "Execute", "sproc_link", []interface{}{item})
+}
+
 func TestQueryPartitionKeyRanges(t *testing.T) {
 	expectedRanges := []PartitionKeyRange{
 		PartitionKeyRange{
@@ -345,3 +376,160 @@ func TestQueryPartitionKeyRanges(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, expectedRanges, ranges, "Ranges are different")
 }
+
+func TestReadIndexTransformationProgress(t *testing.T) {
+	client := &ClientStub{}
+	c := &DocumentDB{client, nil}
+	resp := &Response{Header: http.Header{}}
+	resp.Header.Set(HeaderIndexTransformation, "100")
+	client.On("Read", "coll_link", mock.Anything, mock.Anything).Return(resp, nil)
+	progress, err := c.ReadIndexTransformationProgress("coll_link")
+	assert.NoError(t, err)
+	assert.Equal(t, 100, progress)
+}
+
+func TestReadMap(t *testing.T) {
+	client := &ClientStub{}
+	config := NewConfig(&Key{Key: "YXJpZWwNCg=="})
+	c := &DocumentDB{client, config}
+	client.On("Read", "doc_link", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		*args.Get(1).(*json.RawMessage) = json.RawMessage(`{"id": "1", "count": 12345678901234567}`)
+	}).Return(&Response{}, nil)
+
+	doc, _, err := c.ReadMap("doc_link")
+	assert.NoError(t, err)
+	assert.Equal(t, "1", doc["id"])
+	assert.IsType(t, float64(0), doc["count"])
+
+	config.UseNumber = true
+	doc, _, err = c.ReadMap("doc_link")
+	assert.NoError(t, err)
+	assert.Equal(t, json.Number("12345678901234567"), doc["count"])
+}
+
+func TestReadMapFailure(t *testing.T) {
+	client := &ClientStub{}
+	c := &DocumentDB{client, nil}
+	client.On("Read", "doc_link", mock.Anything, mock.Anything).Return(nil, errors.New("couldn't read document"))
+	doc, r, err := c.ReadMap("doc_link")
+	assert.Nil(t, doc)
+	assert.Nil(t, r)
+	assert.EqualError(t, err, "couldn't read document")
+}
+
+func TestQueryRaw(t *testing.T) {
+	client := &ClientStub{}
+	c := &DocumentDB{client, nil}
+	page := `{"_rid": "r", "Documents": [{"id": "1"}], "_count": 1}`
+	header := http.Header{}
+	header.Set(HeaderContinuation, "next")
+	client.On("Read", "coll_link/docs/", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		*args.Get(1).(*json.RawMessage) = json.RawMessage(page)
+	}).Return(&Response{Header: header}, nil)
+
+	raw, continuation, r, err := c.QueryRaw("coll_link/", nil)
+	assert.NoError(t, err)
+	assert.JSONEq(t, page, string(raw))
+	assert.Equal(t, "next", continuation)
+	assert.NotNil(t, r)
+}
+
+func TestReadDocumentsFeedMetadata(t *testing.T) {
+	client := &ClientStub{}
+	c := &DocumentDB{client, nil}
+	client.On("Read", "coll_link/docs/", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		json.Unmarshal([]byte(`{"_rid": "coll_rid", "Documents": [{"id": "1"}, {"id": "2"}], "_count": 2}`), args.Get(1))
+	}).Return(&Response{Header: http.Header{}}, nil)
+
+	var docs []Document
+	r, err := c.ReadDocuments("coll_link/", &docs)
+	assert.NoError(t, err)
+	assert.Len(t, docs, 2)
+	assert.Equal(t, &FeedMetadata{Rid: "coll_rid", Count: 2}, r.Feed)
+}
+
+func TestWriteResponseDecoding(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost && r.Header.Get(HeaderUpsert) == "" {
+			w.WriteHeader(http.StatusCreated)
+		}
+		fmt.Fprint(w, `{"id": "1", "_etag": "etag", "_ts": 10}`)
+	}))
+	defer s.Close()
+	c := New(s.URL, NewConfig(&Key{Key: "YXJpZWwNCg=="}))
+	stored := Document{Resource: Resource{Id: "1", Etag: "etag", Ts: 10}}
+
+	var ret Document
+	_, err := c.UpsertAndRead("coll/", `{"id": "1"}`, &ret)
+	assert.NoError(t, err)
+	assert.Equal(t, stored, ret, "should decode the stored version into ret")
+
+	for name, write := range map[string]func(doc *Document) error{
+		"Create":  func(doc *Document) error { _, err := c.CreateDocument("coll/", doc); return err },
+		"Upsert":  func(doc *Document) error { _, err := c.UpsertDocument("coll/", doc); return err },
+		"Replace": func(doc *Document) error { _, err := c.ReplaceDocument("coll/docs/1", doc); return err },
+	} {
+		doc := &Document{Resource: Resource{Id: "1"}}
+		assert.NoError(t, write(doc), name)
+		assert.Equal(t, stored, *doc, name+" should update the document with the stored version")
+	}
+}
+
+func TestReplaceCollection(t *testing.T) {
+	client := &ClientStub{}
+	c := &DocumentDB{client, nil}
+	client.On("Replace", "coll_link", "{}").Return(nil)
+	c.ReplaceCollection("coll_link", "{}")
+	client.AssertCalled(t, "Replace", "coll_link", "{}")
+}
+
+func TestCollectionGeospatialConfig(t *testing.T) {
+	client := &ClientStub{}
+	c := &DocumentDB{client, nil}
+	valid := &Collection{Resource: Resource{Id: "coll"}, GeospatialConfig: &GeospatialConfig{Type: GeospatialGeometry}}
+	client.On("Create", "dbs/colls/", valid).Return(nil)
+	_, err := c.CreateCollection("dbs/", valid)
+	assert.NoError(t, err)
+	client.AssertCalled(t, "Create", "dbs/colls/", valid)
+
+	invalid := Collection{GeospatialConfig: &GeospatialConfig{Type: "geography"}}
+	_, err = c.CreateCollection("dbs/", invalid)
+	assert.EqualError(t, err, `geospatial type "geography" is invalid, must be Geography or Geometry`)
+	_, err = c.ReplaceCollection("coll_link", &invalid)
+	assert.Error(t, err)
+	client.AssertNumberOfCalls(t, "Create", 1)
+	client.AssertNotCalled(t, "Replace", "coll_link", &invalid)
+
+	var coll Collection
+	assert.NoError(t, json.Unmarshal([]byte(`{"id": "coll", "geospatialConfig": {"type": "Geometry"}}`), &coll))
+	assert.Equal(t, GeospatialGeometry, coll.GeospatialConfig.Type)
+}
+
+func TestCollectionAnalyticalStorageTTL(t *testing.T) {
+	client := &ClientStub{}
+	c := &DocumentDB{client, nil}
+	ttl := func(n int) *int { return &n }
+	for _, valid := range []*int{ttl(AnalyticalStorageTTLInfinite), ttl(AnalyticalStorageTTLOff), ttl(3600)} {
+		coll := &Collection{Resource: Resource{Id: "coll"}, AnalyticalStorageTTL: valid}
+		client.On("Create", "dbs/colls/", coll).Return(nil)
+		client.On("Replace", "coll_link", coll).Return(nil)
+		_, err := c.CreateCollection("dbs/", coll)
+		assert.NoError(t, err)
+		_, err = c.ReplaceCollection("coll_link", coll)
+		assert.NoError(t, err)
+	}
+
+	invalid := Collection{AnalyticalStorageTTL: ttl(-2)}
+	_, err := c.CreateCollection("dbs/", invalid)
+	assert.EqualError(t, err, "analytical storage TTL -2 is invalid, must be -1(infinite), 0(off) or a number of seconds")
+	_, err = c.ReplaceCollection("coll_link", &invalid)
+	assert.Error(t, err)
+	client.AssertNumberOfCalls(t, "Create", 3)
+	client.AssertNumberOfCalls(t, "Replace", 3)
+
+	var coll Collection
+	assert.NoError(t, json.Unmarshal([]byte(`{"id": "coll", "analyticalStorageTtl": -1}`), &coll))
+	assert.Equal(t, AnalyticalStorageTTLInfinite, *coll.AnalyticalStorageTTL)
+	b, _ := json.Marshal(Collection{AnalyticalStorageTTL: ttl(AnalyticalStorageTTLOff)})
+	assert.Contains(t, string(b), `"analyticalStorageTtl":0`)
+}
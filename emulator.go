@@ -0,0 +1,26 @@
+package documentdb
+
+import (
+	"crypto/tls"
+	"net/http"
+)
+
+const (
+	// EmulatorURL is the default endpoint of the local CosmosDB emulator
+	EmulatorURL = "https://localhost:8081"
+
+	// EmulatorKey is the well-known master key of the local CosmosDB emulator, it's the same for every installation
+	EmulatorKey = "C2y6yDjf5/R+ob0N8A7Cgv30VRDJIWEHLM+4QDU5DE2nQ9nDuVTqobD4b8mGGyPMbIZnqyMsEcaGQy67XIw/Jw=="
+)
+
+// NewEmulatorConfig creates a config for the local CosmosDB emulator, use it with EmulatorURL:
+//
+//	client := documentdb.New(documentdb.EmulatorURL, documentdb.NewEmulatorConfig())
+//
+// The emulator serves a self-signed certificate, so the config skips the TLS verification.
+// Never use it to connect to a real account.
+func NewEmulatorConfig() *Config {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	return NewConfig(NewKey(EmulatorKey)).WithClient(http.Client{Transport: transport})
+}
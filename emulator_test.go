@@ -0,0 +1,171 @@
+//go:build emulator
+
+// Integration tests against the local CosmosDB emulator, run them with:
+//
+//	go test -tags emulator -run Emulator ./...
+//
+// Set DOCUMENTDB_EMULATOR_URL to use an emulator that doesn't listen on EmulatorURL.
+package documentdb
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type emulatorDoc struct {
+	Document
+	Tenant string `json:"tenant"`
+	Value  int    `json:"value"`
+}
+
+// Create a client for the emulator and a partitioned collection dropped at the end of the test
+func EmulatorFactory(t *testing.T) (*DocumentDB, *Collection) {
+	url := os.Getenv("DOCUMENTDB_EMULATOR_URL")
+	if url == "" {
+		url = EmulatorURL
+	}
+	c := New(url, NewEmulatorConfig())
+	db, err := c.CreateDatabase(Database{Resource: Resource{Id: "test-" + strconv.FormatInt(time.Now().UnixNano(), 36)}})
+	require.NoError(t, err, "the emulator must be running at %s", url)
+	t.Cleanup(func() {
+		c.DeleteDatabase(db.Self)
+	})
+	coll, err := c.CreateCollection(db.Self, Collection{
+		Resource:     Resource{Id: "coll"},
+		PartitionKey: &PartitionKeyDefinition{Paths: []string{"/tenant"}, Kind: "Hash", Version: 2},
+	})
+	require.NoError(t, err)
+	return c, coll
+}
+
+func TestEmulatorCRUD(t *testing.T) {
+	assert := assert.New(t)
+	c, coll := EmulatorFactory(t)
+
+	doc := &emulatorDoc{Document: Document{Resource: Resource{Id: "1"}}, Tenant: "a", Value: 1}
+	_, err := c.CreateDocument(coll.Self, doc, PartitionKey("a"))
+	require.NoError(t, err)
+	assert.NotEmpty(doc.Self)
+	assert.NotEmpty(doc.Etag)
+
+	var read emulatorDoc
+	require.NoError(t, c.ReadDocument(doc.Self, &read, PartitionKey("a")))
+	assert.Equal(1, read.Value)
+
+	read.Value = 2
+	_, err = c.ReplaceDocument(read.Self, &read, PartitionKey("a"), IfMatch(read.Etag))
+	assert.NoError(err)
+	_, err = c.ReplaceDocument(read.Self, &read, PartitionKey("a"), IfMatch(doc.Etag))
+	assert.Equal(http.StatusPreconditionFailed, emulatorStatus(err))
+
+	_, err = c.UpsertDocument(coll.Self, &emulatorDoc{Document: Document{Resource: Resource{Id: "1"}}, Tenant: "a", Value: 3}, PartitionKey("a"))
+	assert.NoError(err)
+	require.NoError(t, c.ReadDocument(doc.Self, &read, PartitionKey("a")))
+	assert.Equal(3, read.Value)
+
+	_, err = c.DeleteDocument(doc.Self, PartitionKey("a"))
+	assert.NoError(err)
+	err = c.ReadDocument(doc.Self, &read, PartitionKey("a"))
+	assert.Equal(http.StatusNotFound, emulatorStatus(err))
+}
+
+func TestEmulatorErrors(t *testing.T) {
+	assert := assert.New(t)
+	c, coll := EmulatorFactory(t)
+
+	doc := &emulatorDoc{Document: Document{Resource: Resource{Id: "1"}}, Tenant: "a"}
+	_, err := c.CreateDocument(coll.Self, doc, PartitionKey("a"))
+	require.NoError(t, err)
+	_, err = c.CreateDocument(coll.Self, doc, PartitionKey("a"))
+	assert.Equal(http.StatusConflict, emulatorStatus(err))
+
+	// The partition key of the request must match the document
+	_, err = c.CreateDocument(coll.Self, &emulatorDoc{Document: Document{Resource: Resource{Id: "2"}}, Tenant: "a"}, PartitionKey("b"))
+	assert.Equal(http.StatusBadRequest, emulatorStatus(err))
+
+	_, err = c.QueryDocuments(coll.Self, NewQuery("SELECT * FROM"), &[]emulatorDoc{}, CrossPartition())
+	assert.Equal(http.StatusBadRequest, emulatorStatus(err))
+
+	_, err = c.ReadCollection(coll.Self + "missing/")
+	assert.Error(err)
+}
+
+func TestEmulatorQueryContinuation(t *testing.T) {
+	assert := assert.New(t)
+	c, coll := EmulatorFactory(t)
+
+	docs := make([]interface{}, 25)
+	for i := range docs {
+		docs[i] = &emulatorDoc{Document: Document{Resource: Resource{Id: strconv.Itoa(i)}}, Tenant: strconv.Itoa(i % 3), Value: i}
+	}
+	result, err := c.BulkUpsert(context.Background(), coll.Self, docs, &BulkOptions{PartitionBatching: true})
+	require.NoError(t, err)
+	require.Equal(t, len(docs), result.Succeeded)
+
+	var (
+		page  []emulatorDoc
+		seen  = make(map[string]bool)
+		pages int
+	)
+	iterator := NewIterator(c, NewDocumentIterator(coll.Self, NewQuery("SELECT * FROM c"), &page, Limit(10), CrossPartition()))
+	for iterator.Next() {
+		for _, doc := range page {
+			seen[doc.Id] = true
+		}
+		page = page[:0]
+		pages++
+	}
+	assert.NoError(iterator.Error())
+	assert.Len(seen, len(docs))
+	assert.True(pages >= 3, "expected at least 3 pages, got %d", pages)
+
+	var single []emulatorDoc
+	_, err = c.QueryDocuments(coll.Self, NewQuery("SELECT * FROM c WHERE c.id = @id", P{"@id", "4"}), &single, PartitionKey("1"))
+	if assert.NoError(err) && assert.Len(single, 1) {
+		assert.Equal(4, single[0].Value)
+	}
+}
+
+func TestEmulatorBatchAndRouting(t *testing.T) {
+	assert := assert.New(t)
+	c, coll := EmulatorFactory(t)
+
+	results, _, err := c.ExecuteBatch(coll.Self, "a", []BatchOperation{
+		{OperationType: BatchCreate, ResourceBody: emulatorDoc{Document: Document{Resource: Resource{Id: "1"}}, Tenant: "a"}},
+		{OperationType: BatchUpsert, ResourceBody: emulatorDoc{Document: Document{Resource: Resource{Id: "2"}}, Tenant: "a"}},
+	})
+	require.NoError(t, err)
+	assert.Len(results, 2)
+
+	_, _, err = c.ExecuteBatch(coll.Self, "a", []BatchOperation{
+		{OperationType: BatchUpsert, ResourceBody: emulatorDoc{Document: Document{Resource: Resource{Id: "3"}}, Tenant: "a"}},
+		{OperationType: BatchCreate, ResourceBody: emulatorDoc{Document: Document{Resource: Resource{Id: "1"}}, Tenant: "a"}},
+	})
+	if batchErr, ok := err.(*BatchError); assert.True(ok, "expected a *BatchError, got %v", err) {
+		assert.Equal(1, batchErr.Index)
+		assert.Equal(http.StatusConflict, batchErr.Results[1].StatusCode)
+	}
+
+	m, err := c.ReadRoutingMap(coll.Self)
+	require.NoError(t, err)
+	r, err := m.Resolve("a")
+	assert.NoError(err)
+	var docs []emulatorDoc
+	_, err = c.ReadDocuments(coll.Self, &docs, ChangeFeed(), ChangeFeedPartitionRangeID(r.PartitionKeyRangeID))
+	assert.NoError(err)
+	assert.Len(docs, 2, "the documents of the partition must be served by the resolved range")
+}
+
+func emulatorStatus(err error) int {
+	if reqErr, ok := asRequestError(err); ok {
+		return reqErr.StatusCode
+	}
+	return 0
+}
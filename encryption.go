@@ -0,0 +1,52 @@
+package documentdb
+
+import "fmt"
+
+// EncryptionKeyResolver returns the codec encrypting an included path of a client encryption
+// policy with its client encryption key, e.g: backed by a key vault. The codec must produce the
+// same cipher text for the same value for deterministic paths. Query parameters aren't encoded,
+// so queries can't filter on the encrypted paths, whatever their encryption type.
+type EncryptionKeyResolver func(path ClientEncryptionIncludedPath) (FieldCodec, error)
+
+// FieldCodecs returns the codecs of the included paths of the policy, to set as Config.FieldCodecs
+func (p *ClientEncryptionPolicy) FieldCodecs(resolve EncryptionKeyResolver) (map[string]FieldCodec, error) {
+	if p == nil {
+		return nil, nil
+	}
+	codecs := make(map[string]FieldCodec, len(p.IncludedPaths))
+	for _, path := range p.IncludedPaths {
+		switch path.EncryptionType {
+		case EncryptionTypeDeterministic, EncryptionTypeRandomized:
+		default:
+			return nil, fmt.Errorf("path %s: unsupported encryption type %q", path.Path, path.EncryptionType)
+		}
+		if _, ok := codecs[path.Path]; ok {
+			return nil, fmt.Errorf("path %s is included twice in the client encryption policy", path.Path)
+		}
+		codec, err := resolve(path)
+		if err != nil {
+			return nil, fmt.Errorf("path %s: resolve key %s: %w", path.Path, path.ClientEncryptionKeyID, err)
+		}
+		codecs[path.Path] = codec
+	}
+	return codecs, nil
+}
+
+// Read the client encryption policy of a collection by self link and return the codecs of its
+// included paths. Config.FieldCodecs apply to every collection of a client, so use a client
+// per encrypted collection. The writes that can't encrypt the paths(e.g: stored procedure
+// arguments) fail with ErrFieldCodecsUnsupported instead of storing them in plain text, e.g:
+//
+//	codecs, err := db.ReadFieldCodecs(coll, resolve)
+//	config.FieldCodecs = codecs
+//	encrypted := New(url, config)
+func (c *DocumentDB) ReadFieldCodecs(link string, resolve EncryptionKeyResolver, opts ...CallOption) (map[string]FieldCodec, error) {
+	coll, err := c.ReadCollection(link, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if coll == nil || coll.ClientEncryptionPolicy == nil {
+		return nil, nil
+	}
+	return coll.ClientEncryptionPolicy.FieldCodecs(resolve)
+}
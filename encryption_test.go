@@ -0,0 +1,95 @@
+package documentdb
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadFieldCodecs(t *testing.T) {
+	assert := assert.New(t)
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"id": "coll", "clientEncryptionPolicy": {"includedPaths": [
+			{"path": "/ssn", "clientEncryptionKeyId": "key1", "encryptionType": "Deterministic", "encryptionAlgorithm": "AEAD_AES_256_CBC_HMAC_SHA256"},
+			{"path": "/card", "clientEncryptionKeyId": "key2", "encryptionType": "Randomized", "encryptionAlgorithm": "AEAD_AES_256_CBC_HMAC_SHA256"}
+		], "policyFormatVersion": 1}}`)
+	}))
+	defer s.Close()
+	db := New(s.URL, NewConfig(&Key{Key: "YXJpZWwNCg=="}))
+
+	var keys []string
+	codecs, err := db.ReadFieldCodecs("dbs/db/colls/coll", func(path ClientEncryptionIncludedPath) (FieldCodec, error) {
+		keys = append(keys, path.ClientEncryptionKeyID)
+		assert.Equal(EncryptionAlgorithmAEAD, path.EncryptionAlgorithm)
+		return reverseCodec{}, nil
+	})
+	assert.Nil(err)
+	assert.Equal([]string{"key1", "key2"}, keys)
+	assert.Equal(map[string]FieldCodec{"/ssn": reverseCodec{}, "/card": reverseCodec{}}, codecs)
+
+	_, err = db.ReadFieldCodecs("dbs/db/colls/coll", func(ClientEncryptionIncludedPath) (FieldCodec, error) {
+		return nil, errors.New("key vault unavailable")
+	})
+	assert.EqualError(err, "path /ssn: resolve key key1: key vault unavailable")
+}
+
+func TestClientEncryptionPolicyFieldCodecs(t *testing.T) {
+	assert := assert.New(t)
+	resolve := func(ClientEncryptionIncludedPath) (FieldCodec, error) { return reverseCodec{}, nil }
+
+	codecs, err := (*ClientEncryptionPolicy)(nil).FieldCodecs(resolve)
+	assert.Nil(err)
+	assert.Nil(codecs)
+
+	policy := &ClientEncryptionPolicy{IncludedPaths: []ClientEncryptionIncludedPath{{Path: "/ssn", EncryptionType: "Plaintext"}}}
+	_, err = policy.FieldCodecs(resolve)
+	assert.EqualError(err, `path /ssn: unsupported encryption type "Plaintext"`)
+
+	policy.IncludedPaths = []ClientEncryptionIncludedPath{
+		{Path: "/ssn", EncryptionType: EncryptionTypeDeterministic},
+		{Path: "/ssn", EncryptionType: EncryptionTypeRandomized},
+	}
+	_, err = policy.FieldCodecs(resolve)
+	assert.EqualError(err, "path /ssn is included twice in the client encryption policy")
+}
+
+func TestFieldCodecsEncryptWrites(t *testing.T) {
+	assert := assert.New(t)
+	var bodies []string
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		bodies = append(bodies, string(b))
+		fmt.Fprint(w, `[{"statusCode": 200}]`)
+	}))
+	defer s.Close()
+	policy := &ClientEncryptionPolicy{IncludedPaths: []ClientEncryptionIncludedPath{
+		{Path: "/ssn", ClientEncryptionKeyID: "key1", EncryptionType: EncryptionTypeDeterministic},
+	}}
+	codecs, err := policy.FieldCodecs(func(ClientEncryptionIncludedPath) (FieldCodec, error) { return reverseCodec{}, nil })
+	assert.Nil(err)
+	config := NewConfig(&Key{Key: "YXJpZWwNCg=="})
+	config.FieldCodecs = codecs
+	c := New(s.URL, config)
+
+	// Batches and patches are encrypted
+	_, _, err = c.ExecuteBatch("dbs/db/colls/coll/", "1", []BatchOperation{
+		{OperationType: BatchCreate, ResourceBody: map[string]string{"id": "1", "ssn": "123-45"}},
+	})
+	assert.Nil(err)
+	_, err = c.PatchDocument("dbs/db/colls/coll/docs/1", []PatchOperation{{Op: PatchReplace, Path: "/ssn", Value: "678-90"}}, nil)
+	assert.Nil(err)
+	if assert.Len(bodies, 2) {
+		assert.NotContains(bodies[0], "123-45")
+		assert.NotContains(bodies[1], "678-90")
+	}
+
+	// Stored procedure arguments can't be encrypted
+	err = c.ExecuteStoredProcedure("dbs/db/colls/coll/sprocs/fn", map[string]string{"ssn": "123-45"}, nil)
+	assert.True(errors.Is(err, ErrFieldCodecsUnsupported))
+	assert.Len(bodies, 2)
+}
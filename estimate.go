@@ -0,0 +1,106 @@
+package documentdb
+
+import (
+	"strconv"
+	"strings"
+)
+
+// QueryMetrics holds the execution metrics of a query page, see EnableQueryMetrics
+type QueryMetrics struct {
+	// RetrievedDocumentCount is the number of documents loaded to evaluate the query
+	RetrievedDocumentCount int64
+	// OutputDocumentCount is the number of documents returned
+	OutputDocumentCount int64
+	// IndexHitRatio is the share(0-1) of the retrieved documents matched through the index
+	IndexHitRatio float64
+	// IndexLookupTimeMs and TotalExecutionTimeMs are the time spent in the index, and overall
+	IndexLookupTimeMs, TotalExecutionTimeMs float64
+}
+
+// EnableQueryMetrics makes the server report the execution metrics of the query, see Response.QueryMetrics
+func EnableQueryMetrics() CallOption {
+	return func(r *Request) error {
+		r.Header.Set(HeaderPopulateQueryMetrics, "true")
+		return nil
+	}
+}
+
+// QueryMetrics returns the execution metrics of a query page, the bool result is false if the response
+// doesn't report them. The metrics of the partition key ranges the page was read from are summed.
+func (r *Response) QueryMetrics() (QueryMetrics, bool) {
+	header := r.Header.Get(HeaderQueryMetrics)
+	if header == "" {
+		return QueryMetrics{}, false
+	}
+	var (
+		m      QueryMetrics
+		ratio  float64
+		ratios int
+	)
+	// Cross partition pages report the metrics of every range, separated by commas
+	for _, metrics := range strings.Split(header, ",") {
+		for _, pair := range strings.Split(metrics, ";") {
+			name, value, ok := strings.Cut(pair, "=")
+			if !ok {
+				continue
+			}
+			v, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+			if err != nil {
+				continue
+			}
+			switch strings.TrimSpace(name) {
+			case "retrievedDocumentCount":
+				m.RetrievedDocumentCount += int64(v)
+			case "outputDocumentCount":
+				m.OutputDocumentCount += int64(v)
+			case "indexUtilizationRatio":
+				ratio += v
+				ratios++
+			case "indexLookupTimeInMs":
+				m.IndexLookupTimeMs += v
+			case "totalExecutionTimeInMs":
+				m.TotalExecutionTimeMs += v
+			}
+		}
+	}
+	if ratios > 0 {
+		m.IndexHitRatio = ratio / float64(ratios)
+	}
+	return m, true
+}
+
+// QueryCostEstimate is the estimated cost of a query, see EstimateQueryCost
+type QueryCostEstimate struct {
+	// RequestCharge is the request units of the first page
+	RequestCharge float64
+	// ItemCharge is the request units per returned item, the charge of the page if it's empty
+	ItemCharge float64
+	// UsesIndex reports whether the documents were matched through the index rather than scanned
+	UsesIndex bool
+	// Metrics holds the execution metrics of the page, when the server reported them
+	Metrics QueryMetrics
+}
+
+// EstimateQueryCost runs the query for its first page, of a single item, with the query metrics
+// enabled, to vet the cost of a query during development without fetching the results.
+//
+// It's an estimate based on a single page: the charge of the following pages depends on the data
+// they read, and a query whose filter matches late in the collection may cost more per item than
+// its first page shows. Pass CrossPartition for queries spanning partitions.
+func (c *DocumentDB) EstimateQueryCost(coll string, query *Query, opts ...CallOption) (*QueryCostEstimate, error) {
+	var docs []interface{}
+	opts = append(opts[:len(opts):len(opts)], MaxItemCount(1), EnableQueryMetrics())
+	resp, err := c.QueryDocuments(coll, query, &docs, opts...)
+	if err != nil {
+		return nil, err
+	}
+	estimate := &QueryCostEstimate{RequestCharge: resp.RequestCharge(), ItemCharge: resp.RequestCharge()}
+	if len(docs) > 0 {
+		estimate.ItemCharge /= float64(len(docs))
+	}
+	if metrics, ok := resp.QueryMetrics(); ok {
+		estimate.Metrics = metrics
+		estimate.UsesIndex = metrics.IndexHitRatio > 0 || metrics.IndexLookupTimeMs > 0
+	}
+	return estimate, nil
+}
@@ -0,0 +1,55 @@
+package documentdb
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryMetrics(t *testing.T) {
+	resp := &Response{Header: http.Header{}}
+	_, ok := resp.QueryMetrics()
+	assert.False(t, ok)
+
+	resp.Header.Set(HeaderQueryMetrics, "totalExecutionTimeInMs=1.5;retrievedDocumentCount=10;outputDocumentCount=2;indexUtilizationRatio=0.5;indexLookupTimeInMs=0.25,"+
+		"totalExecutionTimeInMs=0.5;retrievedDocumentCount=4;outputDocumentCount=1;indexUtilizationRatio=1;indexLookupTimeInMs=0.25")
+	m, ok := resp.QueryMetrics()
+	assert.True(t, ok)
+	assert.Equal(t, QueryMetrics{
+		RetrievedDocumentCount: 14,
+		OutputDocumentCount:    3,
+		IndexHitRatio:          0.75,
+		IndexLookupTimeMs:      0.5,
+		TotalExecutionTimeMs:   2,
+	}, m)
+}
+
+func TestEstimateQueryCost(t *testing.T) {
+	assert := assert.New(t)
+	s, c := BulkServerFactory(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal("1", r.Header.Get(HeaderMaxItemCount))
+		assert.Equal("true", r.Header.Get(HeaderPopulateQueryMetrics))
+		w.Header().Set(HeaderRequestCharge, "3.5")
+		w.Header().Set(HeaderContinuation, "next")
+		if r.Header.Get(HeaderPartitionKey) == "" {
+			w.Header().Set(HeaderQueryMetrics, "retrievedDocumentCount=1000;outputDocumentCount=1;indexUtilizationRatio=0;indexLookupTimeInMs=0")
+		} else {
+			w.Header().Set(HeaderQueryMetrics, "retrievedDocumentCount=1;outputDocumentCount=1;indexUtilizationRatio=1;indexLookupTimeInMs=0.1")
+		}
+		fmt.Fprint(w, `{"Documents": [{"id": "1"}]}`)
+	})
+	defer s.Close()
+
+	estimate, err := c.EstimateQueryCost("dbs/db/colls/coll/", NewQuery("SELECT * FROM c WHERE c.name = 'a'"))
+	assert.NoError(err)
+	assert.Equal(3.5, estimate.RequestCharge)
+	assert.Equal(3.5, estimate.ItemCharge)
+	assert.False(estimate.UsesIndex)
+	assert.Equal(int64(1000), estimate.Metrics.RetrievedDocumentCount)
+
+	estimate, err = c.EstimateQueryCost("dbs/db/colls/coll/", NewQuery("SELECT * FROM c WHERE c.id = '1'"), PartitionKey("1"))
+	assert.NoError(err)
+	assert.True(estimate.UsesIndex)
+}
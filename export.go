@@ -0,0 +1,55 @@
+package documentdb
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+)
+
+// SystemProperties holds the properties CosmosDB adds to every stored document
+var SystemProperties = []string{"_rid", "_self", "_etag", "_attachments", "_ts"}
+
+// ExportDocuments streams all documents of a collection to w as newline-delimited JSON.
+// Documents are fetched page by page using the iterator, so memory is bounded by the
+// page size (see Limit) rather than by the collection size.
+// If stripSystemProperties is true, SystemProperties are removed from every document.
+// It returns the number of documents written.
+func (c *DocumentDB) ExportDocuments(coll string, w io.Writer, stripSystemProperties bool, opts ...CallOption) (n int, err error) {
+	var docs []json.RawMessage
+	buf := buffers.Get().(*bytes.Buffer)
+	defer buffers.Put(buf)
+
+	iterator := NewIterator(c, NewDocumentIterator(coll, nil, &docs, lowPriority(opts)...))
+	for iterator.Next() {
+		for _, doc := range docs {
+			if stripSystemProperties {
+				if doc, err = stripProperties(doc, SystemProperties); err != nil {
+					return n, err
+				}
+			}
+			buf.Reset()
+			if err = json.Compact(buf, doc); err != nil {
+				return n, err
+			}
+			buf.WriteByte('\n')
+			if _, err = w.Write(buf.Bytes()); err != nil {
+				return n, err
+			}
+			n++
+		}
+		docs = docs[:0]
+	}
+	return n, iterator.Error()
+}
+
+// Remove the given top-level properties from a raw json document
+func stripProperties(doc json.RawMessage, props []string) (json.RawMessage, error) {
+	m := make(map[string]json.RawMessage)
+	if err := Serialization.Unmarshal(doc, &m); err != nil {
+		return nil, err
+	}
+	for _, p := range props {
+		delete(m, p)
+	}
+	return Serialization.Marshal(m)
+}
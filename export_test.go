@@ -0,0 +1,63 @@
+package documentdb
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func readDocumentsPage(body string) func(args mock.Arguments) {
+	return func(args mock.Arguments) {
+		if err := json.Unmarshal([]byte(body), args.Get(1)); err != nil {
+			panic(err)
+		}
+	}
+}
+
+func TestExportDocuments(t *testing.T) {
+	client := &ClientStub{}
+	c := &DocumentDB{client, nil}
+	first := &Response{Header: http.Header{}}
+	first.Header.Set(HeaderContinuation, "next")
+	client.On("Read", "coll/docs/", mock.Anything, mock.Anything).
+		Run(readDocumentsPage(`{"Documents": [{"id": "1", "_rid": "a"}, {"id": "2",
+			"_ts": 1}]}`)).Return(first, nil).Once()
+	client.On("Read", "coll/docs/", mock.Anything, mock.Anything).
+		Run(readDocumentsPage(`{"Documents": [{"id": "3", "_self": "s"}]}`)).Return(&Response{Header: http.Header{}}, nil).Once()
+
+	var out bytes.Buffer
+	n, err := c.ExportDocuments("coll/", &out, false)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, n)
+	assert.Equal(t, "{\"id\":\"1\",\"_rid\":\"a\"}\n{\"id\":\"2\",\"_ts\":1}\n{\"id\":\"3\",\"_self\":\"s\"}\n", out.String())
+}
+
+func TestExportDocumentsStripSystemProperties(t *testing.T) {
+	client := &ClientStub{}
+	c := &DocumentDB{client, nil}
+	client.On("Read", "coll/docs/", mock.Anything, mock.Anything).
+		Run(readDocumentsPage(`{"Documents": [{"id": "1", "_rid": "a", "_etag": "e", "_ts": 1}]}`)).
+		Return(&Response{Header: http.Header{}}, nil)
+
+	var out bytes.Buffer
+	n, err := c.ExportDocuments("coll/", &out, true)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, "{\"id\":\"1\"}\n", out.String())
+}
+
+func TestExportDocumentsFailure(t *testing.T) {
+	client := &ClientStub{}
+	c := &DocumentDB{client, nil}
+	client.On("Read", "coll/docs/", mock.Anything, mock.Anything).Return(nil, errors.New("couldn't read documents"))
+
+	var out bytes.Buffer
+	n, err := c.ExportDocuments("coll/", &out, false)
+	assert.EqualError(t, err, "couldn't read documents")
+	assert.Equal(t, 0, n)
+}
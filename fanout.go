@@ -0,0 +1,56 @@
+package documentdb
+
+import (
+	"errors"
+	"reflect"
+)
+
+// QueryDocumentsCrossPartition runs a query on every partition key range of the collection, following
+// the continuation of each one, and appends the documents of all the pages to docs(a pointer to a slice).
+// Ranges split while being read are read from their children, see PartitionKeyRangeIterator.
+//
+// The results are merged in range order: ORDER BY, TOP, DISTINCT, GROUP BY and aggregates apply within each
+// range, not across them. Use QueryDocuments with CrossPartition for queries the gateway can serve as a whole.
+//
+// A query filtering on a single partition key value(see Query.PartitionKey) isn't fanned out: it runs on
+// the partition of that value only.
+func (c *DocumentDB) QueryDocumentsCrossPartition(coll string, query *Query, docs interface{}, opts ...CallOption) error {
+	v := reflect.ValueOf(docs)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Slice {
+		return errors.New("docs must be a pointer to a slice")
+	}
+	all := v.Elem()
+	page := reflect.New(all.Type())
+	// The partition key definition is only read for queries with equality filters
+	if len(query.equalityFilters()) > 0 {
+		def, err := c.ReadPartitionKeyDefinition(coll, opts...)
+		if err != nil && !errors.Is(err, ErrNotPartitioned) {
+			return err
+		}
+		if pk, ok := query.PartitionKey(def); ok {
+			return c.queryPartition(coll, query, all, page, append(opts[:len(opts):len(opts)], PartitionKey(pk))...)
+		}
+	}
+	it := NewPartitionKeyRangeIterator(c, coll, page.Interface(), append(opts[:len(opts):len(opts)], CrossPartition())...).WithQuery(query)
+	for it.Next() {
+		all.Set(reflect.AppendSlice(all, page.Elem()))
+		// Every page is decoded into a new slice, not over the documents of the previous one
+		page.Elem().Set(reflect.Zero(all.Type()))
+	}
+	return it.Error()
+}
+
+// Run a query on a single partition, following its continuations, and append the documents to all
+func (c *DocumentDB) queryPartition(coll string, query *Query, all, page reflect.Value, opts ...CallOption) error {
+	for continuation := ""; ; {
+		resp, err := c.QueryDocuments(coll, query, page.Interface(), append(opts[:len(opts):len(opts)], Continuation(continuation))...)
+		if err != nil {
+			return err
+		}
+		all.Set(reflect.AppendSlice(all, page.Elem()))
+		page.Elem().Set(reflect.Zero(all.Type()))
+		if continuation = resp.Continuation(); continuation == "" {
+			return nil
+		}
+	}
+}
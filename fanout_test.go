@@ -0,0 +1,86 @@
+package documentdb
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryDocumentsCrossPartition(t *testing.T) {
+	assert := assert.New(t)
+	pages := map[string][]string{
+		"0": {`[{"id": "a", "tags": ["x"]}, {"id": "b"}]`, `[{"id": "c"}]`},
+		"1": {`[]`, `[{"id": "d"}]`},
+	}
+	s, c := BulkServerFactory(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/pkranges/") {
+			fmt.Fprint(w, `{"PartitionKeyRanges": [{"id": "0"}, {"id": "1"}]}`)
+			return
+		}
+		assert.Equal("true", r.Header.Get(HeaderCrossPartition))
+		var q Query
+		json.NewDecoder(r.Body).Decode(&q)
+		assert.Equal("SELECT * FROM root r", q.Query)
+		id := r.Header.Get(HeaderPartitionKeyRangeID)
+		page := 0
+		if r.Header.Get(HeaderContinuation) != "" {
+			page = 1
+		} else {
+			w.Header().Set(HeaderContinuation, "next-"+id)
+		}
+		fmt.Fprintf(w, `{"Documents": %s}`, pages[id][page])
+	})
+	defer s.Close()
+
+	var docs []struct {
+		Document
+		Tags []string `json:"tags"`
+	}
+	err := c.QueryDocumentsCrossPartition("dbs/db/colls/coll/", NewQuery("SELECT * FROM root r"), &docs)
+	assert.NoError(err)
+	var ids []string
+	for _, doc := range docs {
+		ids = append(ids, doc.Id)
+	}
+	assert.Equal([]string{"a", "b", "c", "d"}, ids)
+	assert.Equal([]string{"x"}, docs[0].Tags)
+	assert.Nil(docs[2].Tags, "should not be decoded over a previous document")
+
+	assert.EqualError(c.QueryDocumentsCrossPartition("dbs/db/colls/coll/", nil, docs), "docs must be a pointer to a slice")
+}
+
+func TestQueryDocumentsCrossPartitionSinglePartition(t *testing.T) {
+	assert := assert.New(t)
+	var queries int
+	s, c := BulkServerFactory(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/pkranges/"):
+			assert.Fail("should not fan the query out")
+		case r.Method == http.MethodGet:
+			fmt.Fprint(w, `{"id": "coll", "partitionKey": {"paths": ["/city"], "kind": "Hash", "version": 2}}`)
+		default:
+			queries++
+			assert.Equal(`["Paris"]`, r.Header.Get(HeaderPartitionKey))
+			if r.Header.Get(HeaderContinuation) == "" {
+				w.Header().Set(HeaderContinuation, "next")
+				fmt.Fprint(w, `{"Documents": [{"id": "a"}]}`)
+				return
+			}
+			fmt.Fprint(w, `{"Documents": [{"id": "b"}]}`)
+		}
+	})
+	defer s.Close()
+
+	var docs []Document
+	err := c.QueryDocumentsCrossPartition("dbs/db/colls/coll/", NewQuery("SELECT * FROM c WHERE c.city = @city", P{"@city", "Paris"}), &docs)
+	assert.NoError(err)
+	assert.Equal(2, queries)
+	if assert.Len(docs, 2) {
+		assert.Equal("a", docs[0].Id)
+		assert.Equal("b", docs[1].Id)
+	}
+}
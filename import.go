@@ -0,0 +1,178 @@
+package documentdb
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrMalformedDocument is reported for import lines that aren't valid json
+var ErrMalformedDocument = errors.New("malformed json document")
+
+// ImportOptions configures ImportDocuments, zero values fallback to the defaults
+type ImportOptions struct {
+	// Concurrency is the number of documents upserted in parallel
+	Concurrency int
+	// MaxRetries is the number of times a throttled(429) upsert is retried
+	MaxRetries int
+	// Backoff is the initial delay before retrying a throttled upsert, it doubles on every retry
+	Backoff time.Duration
+	// Progress, if set, is called after every processed line with the running totals
+	Progress func(imported, failed int)
+}
+
+// ImportError describes a line that couldn't be imported
+type ImportError struct {
+	Line int
+	Err  error
+}
+
+// Implement Error function
+func (e ImportError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+// ImportResult holds the outcome of ImportDocuments
+type ImportResult struct {
+	Imported int
+	// Errors sorted by line number
+	Errors []ImportError
+}
+
+type importLine struct {
+	n   int
+	doc []byte
+	err error
+}
+
+// ImportDocuments reads newline-delimited JSON from r and upserts every line into the collection.
+// Throttled upserts are retried with exponential backoff. Malformed or failing lines don't abort
+// the import, they are reported in ImportResult.Errors instead. Documents over the size limit
+// can't be split, their errors match ErrPayloadTooLarge.
+// In partitioned collections, every line is upserted with its partition key, lines missing it fail.
+// The returned error is set only if reading the partition key definition or r failed, or ctx was cancelled.
+func (c *DocumentDB) ImportDocuments(ctx context.Context, coll string, r io.Reader, opts *ImportOptions, callOpts ...CallOption) (*ImportResult, error) {
+	if opts == nil {
+		opts = &ImportOptions{}
+	}
+	pkPath := ""
+	def, err := c.ReadPartitionKeyDefinition(coll, WithContext(ctx))
+	switch {
+	case err == nil && len(def.Paths) > 0:
+		pkPath = def.Paths[0]
+	case err != nil && ctx.Err() != nil:
+		return &ImportResult{}, ctx.Err()
+	case err != nil && err != ErrNotPartitioned:
+		return nil, err
+	}
+	callOpts = throttledOptions(ctx, callOpts)
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultBulkConcurrency
+	}
+
+	var (
+		readErr error
+		wg      sync.WaitGroup
+		lines   = make(chan importLine)
+		done    = make(chan importLine)
+	)
+
+	go func() {
+		defer close(lines)
+		reader := bufio.NewReader(r)
+		for n := 1; ; n++ {
+			line, err := reader.ReadBytes('\n')
+			if line = bytes.TrimSpace(line); len(line) > 0 {
+				select {
+				case lines <- importLine{n: n, doc: line}:
+				case <-ctx.Done():
+					return
+				}
+			}
+			if err != nil {
+				if err != io.EOF {
+					readErr = err
+				}
+				return
+			}
+		}
+	}()
+
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for l := range lines {
+				l.err = c.importDocument(ctx, coll, l.doc, pkPath, opts, callOpts)
+				done <- l
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	result := &ImportResult{}
+	for l := range done {
+		if l.err != nil {
+			result.Errors = append(result.Errors, ImportError{Line: l.n, Err: l.err})
+		} else {
+			result.Imported++
+		}
+		if opts.Progress != nil {
+			opts.Progress(result.Imported, len(result.Errors))
+		}
+	}
+	sort.Slice(result.Errors, func(i, j int) bool {
+		return result.Errors[i].Line < result.Errors[j].Line
+	})
+
+	if readErr != nil {
+		return result, readErr
+	}
+	return result, ctx.Err()
+}
+
+// Upsert a single import line with its partition key, retrying on throttling
+func (c *DocumentDB) importDocument(ctx context.Context, coll string, doc []byte, pkPath string, opts *ImportOptions, callOpts []CallOption) error {
+	if !json.Valid(doc) {
+		return ErrMalformedDocument
+	}
+	if pkPath != "" {
+		pk, err := ValidatePartitionKey(doc, pkPath)
+		if err != nil {
+			return err
+		}
+		callOpts = append(callOpts, PartitionKey(pk))
+	}
+	return c.upsertThrottled(ctx, coll, doc, opts, callOpts)
+}
+
+// Upsert a single document, retrying on throttling according to the import options
+func (c *DocumentDB) upsertThrottled(ctx context.Context, coll string, doc interface{}, opts *ImportOptions, callOpts []CallOption) error {
+	maxRetries := opts.MaxRetries
+	if maxRetries <= 0 {
+		maxRetries = DefaultBulkMaxRetries
+	}
+	backoff := opts.Backoff
+	if backoff <= 0 {
+		backoff = DefaultBulkBackoff
+	}
+	_, err := retryThrottled(ctx, maxRetries, backoff, func() error {
+		_, err := c.client.Upsert(coll+"docs/", doc, nil, callOpts...)
+		return err
+	}, func() {
+		c.observeRetry("Upsert", RetryReasonThrottled)
+	})
+	return err
+}
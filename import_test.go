@@ -0,0 +1,111 @@
+package documentdb
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImportDocuments(t *testing.T) {
+	var (
+		mu        sync.Mutex
+		upserted  []string
+		throttled bool
+	)
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			w.Write([]byte(`{"id": "coll", "partitionKey": {"paths": ["/id"], "kind": "Hash"}}`))
+			return
+		}
+		b, _ := ioutil.ReadAll(r.Body)
+		var doc Document
+		json.Unmarshal(b, &doc)
+		assert.Equal(t, "true", r.Header.Get(HeaderUpsert))
+		assert.Equal(t, `["`+doc.Id+`"]`, r.Header.Get(HeaderPartitionKey))
+		assert.Equal(t, string(PriorityLow), r.Header.Get(HeaderPriorityLevel))
+
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case doc.Id == "2" && !throttled:
+			throttled = true
+			http.Error(w, `{"code": "429", "message": "Request rate is large"}`, http.StatusTooManyRequests)
+		case doc.Id == "4":
+			http.Error(w, `{"code": "400", "message": "Bad request"}`, http.StatusBadRequest)
+		default:
+			upserted = append(upserted, doc.Id)
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+	defer s.Close()
+	c := New(s.URL, NewConfig(&Key{Key: "YXJpZWwNCg=="}))
+
+	input := strings.Join([]string{
+		`{"id": "1"}`,
+		`{"id": "2"}`,
+		``,
+		`{"id": `,
+		`{"id": "4"}`,
+		`{"id": "5"}`,
+		`{"name": "6"}`,
+	}, "\n")
+	var progress int
+	result, err := c.ImportDocuments(context.Background(), "coll/", strings.NewReader(input), &ImportOptions{
+		Concurrency: 2,
+		Backoff:     time.Millisecond,
+		Progress: func(imported, failed int) {
+			progress = imported + failed
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, result.Imported)
+	assert.ElementsMatch(t, []string{"1", "2", "5"}, upserted)
+	assert.Equal(t, 6, progress)
+	if assert.Len(t, result.Errors, 3) {
+		assert.Equal(t, 4, result.Errors[0].Line)
+		assert.Equal(t, ErrMalformedDocument, result.Errors[0].Err)
+		assert.Equal(t, 5, result.Errors[1].Line)
+		assert.Equal(t, http.StatusBadRequest, result.Errors[1].Err.(*RequestError).StatusCode)
+		assert.Equal(t, 7, result.Errors[2].Line)
+		assert.EqualError(t, result.Errors[2].Err, "partition key /id is missing in document")
+	}
+}
+
+func TestImportDocumentsCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	c := New("http://localhost", NewConfig(&Key{Key: "YXJpZWwNCg=="}))
+	result, err := c.ImportDocuments(ctx, "coll/", strings.NewReader(`{"id": "1"}`), nil)
+	assert.Equal(t, context.Canceled, err)
+	assert.Equal(t, 0, result.Imported)
+}
+
+func TestImportDocumentsNotPartitioned(t *testing.T) {
+	var upserted int
+	s, c := BulkServerFactory(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			w.Write([]byte(`{"id": "coll"}`))
+			return
+		}
+		assert.Equal(t, "true", r.Header.Get(HeaderUpsert))
+		assert.Empty(t, r.Header.Get(HeaderPartitionKey))
+		upserted++
+		w.Write([]byte(`{}`))
+	})
+	defer s.Close()
+
+	input := strings.Repeat(`{"id": "1"}`+"\n", 20)
+	callOpts := []CallOption{ConsistencyLevel(Session), SessionToken("token"), Idempotent(true)}
+	result, err := c.ImportDocuments(context.Background(), "coll/", strings.NewReader(input), &ImportOptions{Concurrency: 10}, callOpts...)
+	assert.NoError(t, err)
+	assert.Equal(t, 20, result.Imported)
+	assert.Equal(t, 20, upserted)
+}
@@ -0,0 +1,105 @@
+// Package prometheus implements documentdb.MetricsObserver and documentdb.CallObserver with Prometheus collectors.
+// It lives in its own module, so the documentdb package doesn't depend on Prometheus.
+package prometheus
+
+import (
+	"strconv"
+
+	"github.com/a8m/documentdb"
+	prom "github.com/prometheus/client_golang/prometheus"
+)
+
+// Observer exposes the documentdb requests as Prometheus metrics
+type Observer struct {
+	requests      *prom.CounterVec
+	errors        *prom.CounterVec
+	retries       *prom.CounterVec
+	requestCharge *prom.HistogramVec
+	latency       *prom.HistogramVec
+	calls         *prom.CounterVec
+	callLatency   *prom.HistogramVec
+}
+
+// NewObserver creates an observer with all metrics prefixed by namespace(e.g: "myapp_documentdb")
+func NewObserver(namespace string) *Observer {
+	labels := []string{"operation", "resource_type", "endpoint"}
+	return &Observer{
+		requests: prom.NewCounterVec(prom.CounterOpts{
+			Namespace: namespace,
+			Name:      "requests_total",
+			Help:      "Number of requests sent, by status code.",
+		}, append(labels, "status_code")),
+		errors: prom.NewCounterVec(prom.CounterOpts{
+			Namespace: namespace,
+			Name:      "errors_total",
+			Help:      "Number of requests that failed or returned an error status code.",
+		}, labels),
+		retries: prom.NewCounterVec(prom.CounterOpts{
+			Namespace: namespace,
+			Name:      "retries_total",
+			Help:      "Number of retried requests, by reason.",
+		}, []string{"operation", "reason"}),
+		requestCharge: prom.NewHistogramVec(prom.HistogramOpts{
+			Namespace: namespace,
+			Name:      "request_charge",
+			Help:      "Request units consumed per request.",
+			Buckets:   prom.ExponentialBuckets(1, 2, 12),
+		}, labels),
+		latency: prom.NewHistogramVec(prom.HistogramOpts{
+			Namespace: namespace,
+			Name:      "request_duration_seconds",
+			Help:      "Request latency in seconds.",
+			Buckets:   prom.DefBuckets,
+		}, labels),
+		calls: prom.NewCounterVec(prom.CounterOpts{
+			Namespace: namespace,
+			Name:      "calls_total",
+			Help:      "Number of calls, by the status code of their last attempt.",
+		}, []string{"operation", "resource_type", "status_code"}),
+		callLatency: prom.NewHistogramVec(prom.HistogramOpts{
+			Namespace: namespace,
+			Name:      "call_duration_seconds",
+			Help:      "Call latency in seconds, retries included.",
+			Buckets:   prom.DefBuckets,
+		}, []string{"operation", "resource_type"}),
+	}
+}
+
+// Register registers all the collectors of the observer
+func (o *Observer) Register(registerer prom.Registerer) error {
+	for _, c := range []prom.Collector{o.requests, o.errors, o.retries, o.requestCharge, o.latency, o.calls, o.callLatency} {
+		if err := registerer.Register(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ObserveRequest implements documentdb.MetricsObserver
+func (o *Observer) ObserveRequest(m documentdb.RequestMetrics) {
+	labels := prom.Labels{
+		"operation":     m.Operation,
+		"resource_type": m.ResourceType,
+		"endpoint":      m.Endpoint,
+	}
+	o.latency.With(labels).Observe(m.Duration.Seconds())
+	if m.Err != nil || m.StatusCode >= 400 {
+		o.errors.With(labels).Inc()
+	}
+	if m.Err == nil {
+		o.requestCharge.With(labels).Observe(m.RequestCharge)
+	}
+	labels["status_code"] = strconv.Itoa(m.StatusCode)
+	o.requests.With(labels).Inc()
+}
+
+// ObserveRetry implements documentdb.MetricsObserver
+func (o *Observer) ObserveRetry(operation, reason string) {
+	o.retries.WithLabelValues(operation, reason).Inc()
+}
+
+// ObserveCall implements documentdb.CallObserver
+func (o *Observer) ObserveCall(m documentdb.CallMetrics) {
+	o.callLatency.WithLabelValues(m.Operation, m.ResourceType).Observe(m.Duration.Seconds())
+	o.calls.WithLabelValues(m.Operation, m.ResourceType, strconv.Itoa(m.StatusCode)).Inc()
+}
@@ -0,0 +1,50 @@
+package prometheus
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/a8m/documentdb"
+	prom "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	_ documentdb.MetricsObserver = &Observer{}
+	_ documentdb.CallObserver    = &Observer{}
+)
+
+func TestObserver(t *testing.T) {
+	o := NewObserver("test")
+	assert.NoError(t, o.Register(prom.NewRegistry()))
+
+	o.ObserveRequest(documentdb.RequestMetrics{
+		Operation:     "Read",
+		ResourceType:  "docs",
+		Endpoint:      "localhost",
+		StatusCode:    200,
+		Duration:      10 * time.Millisecond,
+		RequestCharge: 1,
+	})
+	o.ObserveRequest(documentdb.RequestMetrics{Operation: "Read", ResourceType: "docs", Endpoint: "localhost", StatusCode: 429})
+	o.ObserveRequest(documentdb.RequestMetrics{Operation: "Read", ResourceType: "docs", Endpoint: "localhost", Err: errors.New("timeout")})
+	o.ObserveRetry("Read", documentdb.RetryReasonThrottled)
+
+	assert.Equal(t, float64(1), testutil.ToFloat64(o.requests.WithLabelValues("Read", "docs", "localhost", "200")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(o.requests.WithLabelValues("Read", "docs", "localhost", "429")))
+	assert.Equal(t, float64(2), testutil.ToFloat64(o.errors.WithLabelValues("Read", "docs", "localhost")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(o.retries.WithLabelValues("Read", "throttled")))
+}
+
+func TestObserverCalls(t *testing.T) {
+	o := NewObserver("test")
+	assert.NoError(t, o.Register(prom.NewRegistry()))
+
+	o.ObserveCall(documentdb.CallMetrics{Operation: "Read", ResourceType: "docs", StatusCode: 503, Attempts: 4, Err: errors.New("unavailable")})
+	o.ObserveCall(documentdb.CallMetrics{Operation: "Read", ResourceType: "docs", StatusCode: 200, Attempts: 1})
+
+	assert.Equal(t, float64(1), testutil.ToFloat64(o.calls.WithLabelValues("Read", "docs", "503")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(o.calls.WithLabelValues("Read", "docs", "200")))
+}
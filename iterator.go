@@ -1,5 +1,11 @@
 package documentdb
 
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
 // Iterator allows easily fetch multiple result sets when response max item limit is reacheds
 type Iterator struct {
 	continuationToken string
@@ -8,6 +14,8 @@ type Iterator struct {
 	next              bool
 	source            IteratorFunc
 	db                *DocumentDB
+	pages, maxPages   int
+	truncated         bool
 }
 
 // NewIterator creates iterator instance
@@ -19,6 +27,19 @@ func NewIterator(db *DocumentDB, source IteratorFunc) *Iterator {
 	}
 }
 
+// WithMaxPages bounds the number of pages the iterator fetches, to guard against
+// accidental full scans of large collections. Zero means no limit.
+func (di *Iterator) WithMaxPages(n int) *Iterator {
+	di.maxPages = n
+	return di
+}
+
+// Truncated reports whether the iteration stopped at the max pages bound while
+// more pages were left
+func (di *Iterator) Truncated() bool {
+	return di.truncated
+}
+
 // Response returns *Response object from last call
 func (di *Iterator) Response() *Response {
 	return di.response
@@ -34,10 +55,15 @@ func (di *Iterator) Next() bool {
 	if !di.next {
 		return false
 	}
-	di.response, di.err = di.source(di.db, Continuation(di.continuationToken))
+	if di.maxPages > 0 && di.pages == di.maxPages {
+		di.next, di.truncated = false, true
+		return false
+	}
+	di.response, di.err = di.source(di.db, Continuation(di.continuationToken), ContinuationExpected(true))
 	if di.err != nil {
 		return false
 	}
+	di.pages++
 	di.continuationToken = di.response.Continuation()
 	next := di.next
 	di.next = di.continuationToken != ""
@@ -53,3 +79,196 @@ func NewDocumentIterator(coll string, query *Query, docs interface{}, opts ...Ca
 		return db.QueryDocuments(coll, query, docs, append(opts, internalOpts...)...)
 	}
 }
+
+// QueryIterator reads the pages of a query one at a time, so scanning a large collection doesn't
+// hold more than a page in memory. See NewQueryIterator.
+type QueryIterator struct {
+	db           *DocumentDB
+	coll         string
+	query        *Query
+	opts         []CallOption
+	continuation string
+	done         bool
+}
+
+// NewQueryIterator creates iterator over the pages of a query, a nil query reads the documents feed.
+// The options(e.g: PartitionKey, ConsistencyLevel) are sent with every page request.
+func (c *DocumentDB) NewQueryIterator(coll string, query *Query, opts ...CallOption) *QueryIterator {
+	return &QueryIterator{db: c, coll: coll, query: query, opts: opts}
+}
+
+// HasNext reports whether pages are left to read
+func (it *QueryIterator) HasNext() bool {
+	return !it.done
+}
+
+// Next decodes the next page into docs, a pointer to a slice. Pass a new slice on every call, so the
+// documents of a page aren't decoded over the ones of the previous page. A page that failed is
+// requested again by the next call.
+func (it *QueryIterator) Next(docs interface{}) (*Response, error) {
+	if it.done {
+		return nil, errors.New("the query has no pages left")
+	}
+	opts := append(it.opts[:len(it.opts):len(it.opts)], Continuation(it.continuation), ContinuationExpected(true))
+	resp, err := it.db.QueryDocuments(it.coll, it.query, docs, opts...)
+	if err != nil {
+		return nil, err
+	}
+	it.continuation = resp.Continuation()
+	it.done = it.continuation == ""
+	return resp, nil
+}
+
+const (
+	// SubStatusPartitionKeyRangeGone is returned with 410 when the range was split or merged
+	SubStatusPartitionKeyRangeGone = 1002
+
+	// SubStatusCompletingSplit is returned with 410 while the range split is still in progress
+	SubStatusCompletingSplit = 1007
+)
+
+// IsPartitionSplit checks whether the error indicates that the partition key range
+// being read was split, and the caller should continue with its child ranges
+func IsPartitionSplit(err error) bool {
+	reqErr, ok := err.(*RequestError)
+	return ok && reqErr.StatusCode == http.StatusGone &&
+		(reqErr.SubStatus == SubStatusPartitionKeyRangeGone || reqErr.SubStatus == SubStatusCompletingSplit)
+}
+
+// PartitionKeyRangeIterator reads a collection feed (e.g: the change feed, using the ChangeFeed option)
+// partition key range by range. When a range is split while being read, its child ranges are resolved
+// and read from the continuation of the parent, so no document is lost or read twice.
+type PartitionKeyRangeIterator struct {
+	db       *DocumentDB
+	coll     string
+	docs     interface{}
+	query    *Query
+	opts     []CallOption
+	ranges   []rangeState
+	loaded   bool
+	response *Response
+	err      error
+	store    CheckpointStore
+	// last is the position after the last page read, see Checkpoint
+	last rangeState
+}
+
+type rangeState struct {
+	id           string
+	continuation string
+}
+
+// NewPartitionKeyRangeIterator creates iterator that reads all the partition key ranges of the collection.
+// Every call to Next decodes a single page of a single range into docs.
+func NewPartitionKeyRangeIterator(db *DocumentDB, coll string, docs interface{}, opts ...CallOption) *PartitionKeyRangeIterator {
+	return &PartitionKeyRangeIterator{
+		db:   db,
+		coll: coll,
+		docs: docs,
+		opts: opts,
+	}
+}
+
+// WithQuery runs the query on every range instead of reading its feed, see QueryDocumentsCrossPartition
+func (it *PartitionKeyRangeIterator) WithQuery(query *Query) *PartitionKeyRangeIterator {
+	it.query = query
+	return it
+}
+
+// WithCheckpointStore resumes every range from the continuation stored for it, ranges split
+// since then resume from the checkpoint of their parent. Call Checkpoint to store the progress.
+func (it *PartitionKeyRangeIterator) WithCheckpointStore(store CheckpointStore) *PartitionKeyRangeIterator {
+	it.store = store
+	return it
+}
+
+// Checkpoint stores the position after the last page read, call it once the page is processed.
+// A range read to its end keeps the continuation of its last page, that is read again on resume.
+func (it *PartitionKeyRangeIterator) Checkpoint() error {
+	if it.store == nil {
+		return errors.New("the iterator has no checkpoint store")
+	}
+	if it.last.id == "" || it.last.continuation == "" {
+		return nil
+	}
+	return it.store.WriteCheckpoint(it.coll, it.last.id, it.last.continuation)
+}
+
+// Response returns *Response object from last call
+func (it *PartitionKeyRangeIterator) Response() *Response {
+	return it.response
+}
+
+// Error returns error from last call
+func (it *PartitionKeyRangeIterator) Error() error {
+	return it.err
+}
+
+// Next reads the next page, it returns false when all ranges were read or on error
+func (it *PartitionKeyRangeIterator) Next() bool {
+	if !it.loaded {
+		ranges, err := it.db.ReadPartitionKeyRanges(it.coll, it.opts...)
+		if err != nil {
+			it.err = err
+			return false
+		}
+		for _, r := range ranges {
+			state := rangeState{id: r.PartitionKeyRangeID}
+			if it.store != nil {
+				if state.continuation, err = readCheckpoint(it.store, it.coll, r); err != nil {
+					it.err = err
+					return false
+				}
+			}
+			it.ranges = append(it.ranges, state)
+		}
+		it.loaded = true
+	}
+	for len(it.ranges) > 0 {
+		current := &it.ranges[0]
+		opts := append(it.opts[:len(it.opts):len(it.opts)],
+			ChangeFeedPartitionRangeID(current.id), Continuation(current.continuation))
+		it.response, it.err = it.db.QueryDocuments(it.coll, it.query, it.docs, opts...)
+		if IsPartitionSplit(it.err) {
+			if it.err = it.split(); it.err != nil {
+				return false
+			}
+			continue
+		}
+		if it.err != nil {
+			return false
+		}
+		it.last = *current
+		if current.continuation = it.response.Continuation(); current.continuation == "" {
+			it.ranges = it.ranges[1:]
+		} else {
+			it.last.continuation = current.continuation
+		}
+		return true
+	}
+	return false
+}
+
+// Replace the current range with its children, that continue from where the parent stopped
+func (it *PartitionKeyRangeIterator) split() error {
+	parent := it.ranges[0]
+	it.db.invalidateMetadata(it.coll)
+	ranges, err := it.db.ReadPartitionKeyRanges(it.coll, it.opts...)
+	if err != nil {
+		return err
+	}
+	var children []rangeState
+	for _, r := range ranges {
+		for _, id := range r.Parents {
+			if id == parent.id {
+				children = append(children, rangeState{id: r.PartitionKeyRangeID, continuation: parent.continuation})
+				break
+			}
+		}
+	}
+	if len(children) == 0 {
+		return fmt.Errorf("partition key range %s is gone and no child range was found", parent.id)
+	}
+	it.ranges = append(children, it.ranges[1:]...)
+	return nil
+}
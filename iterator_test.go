@@ -0,0 +1,219 @@
+package documentdb
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// Apply call options on an empty request to inspect them
+func applyOptions(opts []CallOption) *Request {
+	r, _ := http.NewRequest(http.MethodGet, "link", &bytes.Buffer{})
+	req := ResourceRequest("coll/docs/", r)
+	for _, opt := range opts {
+		opt(req)
+	}
+	return req
+}
+
+func rangeRequest(id, continuation string) interface{} {
+	return mock.MatchedBy(func(opts []CallOption) bool {
+		r := applyOptions(opts)
+		return r.Header.Get(HeaderPartitionKeyRangeID) == id && r.Header.Get(HeaderContinuation) == continuation
+	})
+}
+
+func pageResponse(continuation string) *Response {
+	r := &Response{Header: http.Header{}}
+	r.Header.Set(HeaderContinuation, continuation)
+	return r
+}
+
+func TestIsPartitionSplit(t *testing.T) {
+	assert.True(t, IsPartitionSplit(&RequestError{StatusCode: http.StatusGone, SubStatus: SubStatusPartitionKeyRangeGone}))
+	assert.True(t, IsPartitionSplit(&RequestError{StatusCode: http.StatusGone, SubStatus: SubStatusCompletingSplit}))
+	assert.False(t, IsPartitionSplit(&RequestError{StatusCode: http.StatusGone}))
+	assert.False(t, IsPartitionSplit(&RequestError{StatusCode: http.StatusNotFound}))
+	assert.False(t, IsPartitionSplit(nil))
+}
+
+func TestPartitionKeyRangeIteratorSplit(t *testing.T) {
+	client := &ClientStub{}
+	c := &DocumentDB{client, nil}
+	ranges := func(ranges ...PartitionKeyRange) func(mock.Arguments) {
+		return func(args mock.Arguments) {
+			args.Get(1).(*queryPartitionKeyRangesRequest).Ranges = ranges
+		}
+	}
+	page := func(body string) func(mock.Arguments) {
+		return func(args mock.Arguments) {
+			json.Unmarshal([]byte(body), args.Get(1))
+		}
+	}
+	client.On("Read", "coll/pkranges/", mock.Anything, mock.Anything).
+		Run(ranges(PartitionKeyRange{PartitionKeyRangeID: "0"})).Return(&Response{}, nil).Once()
+	client.On("Read", "coll/pkranges/", mock.Anything, mock.Anything).
+		Run(ranges(
+			PartitionKeyRange{PartitionKeyRangeID: "1", Parents: []string{"0"}},
+			PartitionKeyRange{PartitionKeyRangeID: "2", Parents: []string{"0"}},
+		)).Return(&Response{}, nil).Once()
+	client.On("Read", "coll/docs/", mock.Anything, rangeRequest("0", "")).
+		Run(page(`{"Documents": [{"id": "a"}]}`)).Return(pageResponse("c1"), nil).Once()
+	client.On("Read", "coll/docs/", mock.Anything, rangeRequest("0", "c1")).
+		Return(nil, &RequestError{StatusCode: http.StatusGone, SubStatus: SubStatusPartitionKeyRangeGone}).Once()
+	client.On("Read", "coll/docs/", mock.Anything, rangeRequest("1", "c1")).
+		Run(page(`{"Documents": [{"id": "b"}]}`)).Return(pageResponse(""), nil).Once()
+	client.On("Read", "coll/docs/", mock.Anything, rangeRequest("2", "c1")).
+		Run(page(`{"Documents": [{"id": "c"}]}`)).Return(pageResponse(""), nil).Once()
+
+	var (
+		ids  []string
+		docs []Document
+	)
+	it := NewPartitionKeyRangeIterator(c, "coll/", &docs)
+	for it.Next() {
+		for _, doc := range docs {
+			ids = append(ids, doc.Id)
+		}
+	}
+	assert.NoError(t, it.Error())
+	assert.Equal(t, []string{"a", "b", "c"}, ids)
+	client.AssertExpectations(t)
+}
+
+func TestPartitionKeyRangeIteratorSplitWithoutChildren(t *testing.T) {
+	client := &ClientStub{}
+	c := &DocumentDB{client, nil}
+	client.On("Read", "coll/pkranges/", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		args.Get(1).(*queryPartitionKeyRangesRequest).Ranges = []PartitionKeyRange{{PartitionKeyRangeID: "0"}}
+	}).Return(&Response{}, nil)
+	client.On("Read", "coll/docs/", mock.Anything, mock.Anything).
+		Return(nil, &RequestError{StatusCode: http.StatusGone, SubStatus: SubStatusPartitionKeyRangeGone})
+
+	var docs []Document
+	it := NewPartitionKeyRangeIterator(c, "coll/", &docs)
+	assert.False(t, it.Next())
+	assert.EqualError(t, it.Error(), "partition key range 0 is gone and no child range was found")
+}
+
+func TestIteratorMaxPages(t *testing.T) {
+	client := &ClientStub{}
+	c := &DocumentDB{client, nil}
+	client.On("Read", "coll/docs/", mock.Anything, mock.Anything).Return(pageResponse("next"), nil)
+
+	iterator := NewIterator(c, NewDocumentIterator("coll/", nil, &[]Document{})).WithMaxPages(2)
+	pages := 0
+	for iterator.Next() {
+		pages++
+	}
+	assert.NoError(t, iterator.Error())
+	assert.Equal(t, 2, pages)
+	assert.True(t, iterator.Truncated())
+	client.AssertNumberOfCalls(t, "Read", 2)
+}
+
+func TestIteratorMaxPagesNotReached(t *testing.T) {
+	client := &ClientStub{}
+	c := &DocumentDB{client, nil}
+	client.On("Read", "coll/docs/", mock.Anything, rangeRequest("", "")).Return(pageResponse("next"), nil).Once()
+	client.On("Read", "coll/docs/", mock.Anything, rangeRequest("", "next")).Return(pageResponse(""), nil).Once()
+
+	iterator := NewIterator(c, NewDocumentIterator("coll/", nil, &[]Document{})).WithMaxPages(2)
+	for iterator.Next() {
+	}
+	assert.NoError(t, iterator.Error())
+	assert.False(t, iterator.Truncated())
+}
+
+func TestPartitionKeyRangeIteratorCheckpoint(t *testing.T) {
+	client := &ClientStub{}
+	c := &DocumentDB{client, nil}
+	store := NewMemoryCheckpointStore()
+	ranges := []PartitionKeyRange{{PartitionKeyRangeID: "0"}, {PartitionKeyRangeID: "1"}}
+	client.On("Read", "coll/pkranges/", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		args.Get(1).(*queryPartitionKeyRangesRequest).Ranges = ranges
+	}).Return(&Response{}, nil)
+	client.On("Read", "coll/docs/", mock.Anything, rangeRequest("0", "")).Return(pageResponse("c0"), nil).Once()
+	client.On("Read", "coll/docs/", mock.Anything, rangeRequest("0", "c0")).Return(pageResponse(""), nil).Once()
+	client.On("Read", "coll/docs/", mock.Anything, rangeRequest("1", "")).Return(pageResponse("c1"), nil).Once()
+	client.On("Read", "coll/docs/", mock.Anything, rangeRequest("1", "c1")).Return(pageResponse("c1-next"), nil).Once()
+
+	it := NewPartitionKeyRangeIterator(c, "coll/", &[]Document{}).WithCheckpointStore(store)
+	for pages := 1; it.Next(); pages++ {
+		// Stop before processing the 4th page
+		if pages == 4 {
+			break
+		}
+		assert.NoError(t, it.Checkpoint())
+	}
+	assert.NoError(t, it.Error())
+	client.AssertExpectations(t)
+	for rangeID, continuation := range map[string]string{"0": "c0", "1": "c1"} {
+		checkpoint, _ := store.ReadCheckpoint("coll/", rangeID)
+		assert.Equal(t, continuation, checkpoint, "range %s", rangeID)
+	}
+
+	// On restart, every range resumes from its checkpoint. Range 1 was split into 2 and 3 meanwhile.
+	ranges = []PartitionKeyRange{
+		{PartitionKeyRangeID: "0"},
+		{PartitionKeyRangeID: "2", Parents: []string{"1"}},
+		{PartitionKeyRangeID: "3", Parents: []string{"1"}},
+	}
+	client.On("Read", "coll/docs/", mock.Anything, rangeRequest("0", "c0")).Return(pageResponse(""), nil).Once()
+	client.On("Read", "coll/docs/", mock.Anything, rangeRequest("2", "c1")).Return(pageResponse(""), nil).Once()
+	client.On("Read", "coll/docs/", mock.Anything, rangeRequest("3", "c1")).Return(pageResponse(""), nil).Once()
+	it = NewPartitionKeyRangeIterator(c, "coll/", &[]Document{}).WithCheckpointStore(store)
+	for it.Next() {
+		assert.NoError(t, it.Checkpoint())
+	}
+	assert.NoError(t, it.Error())
+	client.AssertExpectations(t)
+}
+
+func TestPartitionKeyRangeIteratorCheckpointWithoutStore(t *testing.T) {
+	it := NewPartitionKeyRangeIterator(&DocumentDB{}, "coll/", &[]Document{})
+	assert.EqualError(t, it.Checkpoint(), "the iterator has no checkpoint store")
+}
+
+func TestQueryIterator(t *testing.T) {
+	client := &ClientStub{}
+	c := &DocumentDB{client, nil}
+	page := func(continuation string) interface{} {
+		return mock.MatchedBy(func(opts []CallOption) bool {
+			r := applyOptions(opts)
+			return r.Header.Get(HeaderContinuation) == continuation &&
+				len(r.Header[HeaderPartitionKey]) == 1 && r.Header[HeaderPartitionKey][0] == `["1"]` && r.Header.Get(HeaderConsistency) == string(Eventual)
+		})
+	}
+	client.On("Read", "coll/docs/", mock.Anything, page("")).Run(func(args mock.Arguments) {
+		json.Unmarshal([]byte(`{"Documents": [{"id": "a"}, {"id": "b"}]}`), args.Get(1))
+	}).Return(pageResponse("next"), nil).Once()
+	client.On("Read", "coll/docs/", mock.Anything, page("next")).
+		Return(nil, &RequestError{StatusCode: http.StatusServiceUnavailable}).Once()
+	client.On("Read", "coll/docs/", mock.Anything, page("next")).Run(func(args mock.Arguments) {
+		json.Unmarshal([]byte(`{"Documents": [{"id": "c"}]}`), args.Get(1))
+	}).Return(pageResponse(""), nil).Once()
+
+	it := c.NewQueryIterator("coll/", nil, PartitionKey("1"), ConsistencyLevel(Eventual))
+	var ids []string
+	errs := 0
+	for it.HasNext() {
+		var docs []Document
+		if _, err := it.Next(&docs); err != nil {
+			errs++
+			continue
+		}
+		for _, doc := range docs {
+			ids = append(ids, doc.Id)
+		}
+	}
+	assert.Equal(t, []string{"a", "b", "c"}, ids)
+	assert.Equal(t, 1, errs, "the failed page should be requested again")
+	_, err := it.Next(&[]Document{})
+	assert.EqualError(t, err, "the query has no pages left")
+	client.AssertExpectations(t)
+}
@@ -0,0 +1,189 @@
+package documentdb
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// LenientSerialization is a forgiving json driver, meant to be used as Config.FallbackSerialization.
+// Values are coerced to the type of their target where possible(e.g: "1" to 1, 1 to "1", "true" to true)
+// and values that can't be coerced are skipped, instead of failing the whole document.
+var LenientSerialization = SerializationDriver{
+	EncoderFactory: DefaultSerialization.EncoderFactory,
+	DecoderFactory: func(r io.Reader) JSONDecoder {
+		return &lenientDecoder{r}
+	},
+	Marshal:   json.Marshal,
+	Unmarshal: lenientUnmarshal,
+}
+
+type lenientDecoder struct {
+	r io.Reader
+}
+
+func (d *lenientDecoder) Decode(obj interface{}) error {
+	data, err := ioutil.ReadAll(d.r)
+	if err != nil {
+		return err
+	}
+	return lenientUnmarshal(data, obj)
+}
+
+func lenientUnmarshal(data []byte, v interface{}) error {
+	var src interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&src); err != nil {
+		return err
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return &json.InvalidUnmarshalError{Type: reflect.TypeOf(v)}
+	}
+	coerce(rv.Elem(), src)
+	return nil
+}
+
+// Assign the generic json value src to dst, converting it to the kind of dst when needed
+func coerce(dst reflect.Value, src interface{}) {
+	// Like encoding/json, decode into the pointer an interface already holds(e.g: the query envelope)
+	if dst.Kind() == reflect.Interface && !dst.IsNil() && dst.Elem().Kind() == reflect.Ptr && !dst.Elem().IsNil() {
+		coerce(dst.Elem().Elem(), src)
+		return
+	}
+	// Values that are already valid for their target(including custom unmarshalers) take the strict path
+	if dst.CanAddr() {
+		if b, err := json.Marshal(src); err == nil {
+			v := reflect.New(dst.Type())
+			v.Elem().Set(dst)
+			if json.Unmarshal(b, v.Interface()) == nil {
+				dst.Set(v.Elem())
+				return
+			}
+		}
+	}
+	if src == nil {
+		return
+	}
+	switch dst.Kind() {
+	case reflect.Ptr:
+		v := reflect.New(dst.Type().Elem())
+		coerce(v.Elem(), src)
+		dst.Set(v)
+	case reflect.Interface:
+		if v := reflect.ValueOf(src); v.Type().AssignableTo(dst.Type()) {
+			dst.Set(v)
+		}
+	case reflect.Struct:
+		if m, ok := src.(map[string]interface{}); ok {
+			coerceStruct(dst, m)
+		}
+	case reflect.Map:
+		m, ok := src.(map[string]interface{})
+		if !ok || dst.Type().Key().Kind() != reflect.String {
+			return
+		}
+		if dst.IsNil() {
+			dst.Set(reflect.MakeMap(dst.Type()))
+		}
+		for k, elem := range m {
+			v := reflect.New(dst.Type().Elem()).Elem()
+			coerce(v, elem)
+			dst.SetMapIndex(reflect.ValueOf(k).Convert(dst.Type().Key()), v)
+		}
+	case reflect.Slice:
+		arr, ok := src.([]interface{})
+		if !ok {
+			return
+		}
+		s := reflect.MakeSlice(dst.Type(), len(arr), len(arr))
+		for i, elem := range arr {
+			coerce(s.Index(i), elem)
+		}
+		dst.Set(s)
+	case reflect.Array:
+		if arr, ok := src.([]interface{}); ok {
+			for i := 0; i < len(arr) && i < dst.Len(); i++ {
+				coerce(dst.Index(i), arr[i])
+			}
+		}
+	case reflect.String:
+		switch t := src.(type) {
+		case json.Number:
+			dst.SetString(t.String())
+		case bool:
+			dst.SetString(strconv.FormatBool(t))
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if f, ok := toFloat(src); ok && !dst.OverflowInt(int64(f)) {
+			dst.SetInt(int64(f))
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if f, ok := toFloat(src); ok && f >= 0 && !dst.OverflowUint(uint64(f)) {
+			dst.SetUint(uint64(f))
+		}
+	case reflect.Float32, reflect.Float64:
+		if f, ok := toFloat(src); ok {
+			dst.SetFloat(f)
+		}
+	case reflect.Bool:
+		switch t := src.(type) {
+		case string:
+			if b, err := strconv.ParseBool(t); err == nil {
+				dst.SetBool(b)
+			}
+		case json.Number:
+			f, _ := t.Float64()
+			dst.SetBool(f != 0)
+		}
+	}
+}
+
+// Assign the object fields to the struct fields, matching them by their json names
+func coerceStruct(dst reflect.Value, m map[string]interface{}) {
+	t := dst.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" || field.PkgPath != "" {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			coerceStruct(dst.Field(i), m)
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		for k, v := range m {
+			if strings.EqualFold(k, name) {
+				coerce(dst.Field(i), v)
+				break
+			}
+		}
+	}
+}
+
+// Convert a json number, numeric string or bool to float
+func toFloat(src interface{}) (float64, bool) {
+	switch t := src.(type) {
+	case json.Number:
+		f, err := t.Float64()
+		return f, err == nil
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(t), 64)
+		return f, err == nil
+	case bool:
+		if t {
+			return 1, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
@@ -0,0 +1,61 @@
+package documentdb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type lenientUser struct {
+	Document
+	Name    string             `json:"name"`
+	Age     int                `json:"age"`
+	Score   float64            `json:"score"`
+	Active  bool               `json:"active"`
+	Tags    []string           `json:"tags"`
+	Address *struct{ Zip int } `json:"address"`
+	Extra   map[string]int     `json:"extra"`
+}
+
+func TestLenientUnmarshal(t *testing.T) {
+	var user lenientUser
+	err := LenientSerialization.Unmarshal([]byte(`{
+		"id": "1",
+		"name": 42,
+		"age": "31",
+		"score": "9.5",
+		"active": "true",
+		"tags": ["a", 2, true],
+		"address": {"zip": "12345"},
+		"extra": {"a": "1", "b": "not a number"},
+		"unknown": "ignored"
+	}`), &user)
+	assert.NoError(t, err)
+	assert.Equal(t, "1", user.Id)
+	assert.Equal(t, "42", user.Name)
+	assert.Equal(t, 31, user.Age)
+	assert.Equal(t, 9.5, user.Score)
+	assert.True(t, user.Active)
+	assert.Equal(t, []string{"a", "2", "true"}, user.Tags)
+	assert.Equal(t, 12345, user.Address.Zip)
+	assert.Equal(t, map[string]int{"a": 1, "b": 0}, user.Extra)
+}
+
+func TestLenientUnmarshalIntoEnvelope(t *testing.T) {
+	var users []lenientUser
+	data := struct {
+		Documents interface{} `json:"Documents,omitempty"`
+	}{Documents: &users}
+	err := LenientSerialization.Unmarshal([]byte(`{"Documents": [{"id": "1", "age": "20"}, {"id": "2", "age": 30}]}`), &data)
+	assert.NoError(t, err)
+	if assert.Len(t, users, 2) {
+		assert.Equal(t, 20, users[0].Age)
+		assert.Equal(t, 30, users[1].Age)
+	}
+}
+
+func TestLenientUnmarshalInvalidJson(t *testing.T) {
+	var user lenientUser
+	assert.Error(t, LenientSerialization.Unmarshal([]byte(`{"id": `), &user))
+	assert.Error(t, LenientSerialization.Unmarshal([]byte(`{}`), user))
+}
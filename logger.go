@@ -0,0 +1,26 @@
+package documentdb
+
+// Logger receives the diagnostic messages of the client, implement it to feed them
+// to your logging library(e.g: zap or logrus). Implementations must be safe for concurrent use.
+type Logger interface {
+	Debugf(format string, args ...interface{})
+	Infof(format string, args ...interface{})
+	Warnf(format string, args ...interface{})
+}
+
+// NopLogger discards every message, it's the Logger set by NewConfig
+var NopLogger Logger = nopLogger{}
+
+type nopLogger struct{}
+
+func (nopLogger) Debugf(string, ...interface{}) {}
+func (nopLogger) Infof(string, ...interface{})  {}
+func (nopLogger) Warnf(string, ...interface{})  {}
+
+// Return the configured logger, or NopLogger
+func (c *Client) logger() Logger {
+	if c.Config.Logger == nil {
+		return NopLogger
+	}
+	return c.Config.Logger
+}
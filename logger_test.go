@@ -0,0 +1,45 @@
+package documentdb
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type RecordingLogger struct {
+	mu       sync.Mutex
+	Messages []string
+}
+
+func (l *RecordingLogger) log(level, format string, args ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.Messages = append(l.Messages, level+" "+fmt.Sprintf(format, args...))
+}
+
+func (l *RecordingLogger) Debugf(format string, args ...interface{}) { l.log("debug", format, args...) }
+func (l *RecordingLogger) Infof(format string, args ...interface{})  { l.log("info", format, args...) }
+func (l *RecordingLogger) Warnf(format string, args ...interface{})  { l.log("warn", format, args...) }
+
+func TestLogger(t *testing.T) {
+	s, client, _ := RetryServerFactory(http.StatusServiceUnavailable)
+	defer s.Close()
+	logger := &RecordingLogger{}
+	client.Config.Logger = logger
+
+	_, err := client.Read("dbs/db/colls/coll/docs/1", nil, RequestID("req-1"))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"debug documentdb: attempt 1 outgoing request GET /dbs/db/colls/coll/docs/1, request id req-1",
+		"warn documentdb: GET /dbs/db/colls/coll/docs/1 failed, retrying: ServiceUnavailable, try again (request id: req-1)",
+		"debug documentdb: attempt 2 outgoing request GET /dbs/db/colls/coll/docs/1, request id req-1",
+	}, logger.Messages)
+
+	// A nil logger is ignored
+	client.Config.Logger = nil
+	_, err = client.Read("dbs/db/colls/coll/docs/1", nil)
+	assert.NoError(t, err)
+}
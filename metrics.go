@@ -0,0 +1,173 @@
+package documentdb
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	// RetryReasonThrottled is reported when a request is retried after being rate limited
+	RetryReasonThrottled = "throttled"
+
+	// RetryReasonTransient is reported when a request is retried after a transient failure, see RetryOptions
+	RetryReasonTransient = "transient"
+
+	// RetryReasonClockSkew is reported when a request is signed again after being rejected for clock skew
+	RetryReasonClockSkew = "clock_skew"
+
+	// RetryReasonPartitionGone is reported when a request is retried after its partition was split or migrated
+	RetryReasonPartitionGone = "partition_gone"
+)
+
+// RequestMetrics describes a single request sent to the server
+type RequestMetrics struct {
+	// Operation is one of Read, Query, Create, Upsert, Replace, Patch, Delete or Execute
+	Operation    string
+	ResourceType string
+	// Endpoint is the host the request was sent to
+	Endpoint string
+	// StatusCode is zero when no response was received, see Err
+	StatusCode    int
+	Duration      time.Duration
+	RequestCharge float64
+	ActivityID    string
+	// RequestID is the client request id of the call, shared by its retries
+	RequestID string
+	// DiagnosticContext is the application context of the call, see the DiagnosticContext option
+	DiagnosticContext map[string]string
+	// Err is set when the request failed before a response was received
+	Err error
+}
+
+// MetricsObserver receives the outcome of every request and retry, use it to feed
+// your metrics system. Implementations must be safe for concurrent use.
+type MetricsObserver interface {
+	ObserveRequest(m RequestMetrics)
+	ObserveRetry(operation, reason string)
+}
+
+// CallMetrics describes the outcome of a call, once its retries are over
+type CallMetrics struct {
+	Operation    string
+	ResourceType string
+	// StatusCode is the status code of the last attempt, zero if it received no response
+	StatusCode int
+	// Duration is the time taken by all the attempts, backoff included
+	Duration time.Duration
+	// Attempts is the number of requests sent, the initial one and its retries
+	Attempts int
+	// RequestCharge is the total of the request units consumed by the attempts
+	RequestCharge float64
+	// ActivityID is the activity id of the last response
+	ActivityID string
+	RequestID  string
+	// Err is the error the call failed with
+	Err error
+}
+
+// CallObserver is implemented by the MetricsObservers that are also notified of the outcome of every
+// call, e.g: to count the operations that failed once their retries were exhausted.
+type CallObserver interface {
+	ObserveCall(m CallMetrics)
+}
+
+// The outcome of the attempts of a call, see CallMetrics
+type callStats struct {
+	attempts      int
+	statusCode    int
+	requestCharge float64
+	activityID    string
+}
+
+// Record an attempt of the request
+func (req *Request) recordAttempt(resp *http.Response) {
+	req.stats.attempts++
+	req.stats.statusCode, req.stats.activityID = 0, ""
+	if resp != nil {
+		req.stats.statusCode = resp.StatusCode
+		req.stats.requestCharge += (&Response{Header: resp.Header}).RequestCharge()
+		req.stats.activityID = resp.Header.Get(HeaderActivityID)
+	}
+}
+
+// Report the outcome of a call to the configured observer, if it's a CallObserver
+func (c *Client) observeCall(r *Request, err error, duration time.Duration) {
+	observer, ok := c.Config.MetricsObserver.(CallObserver)
+	if !ok {
+		return
+	}
+	observer.ObserveCall(CallMetrics{
+		Operation:     r.operation(),
+		ResourceType:  r.rType,
+		StatusCode:    r.stats.statusCode,
+		Duration:      duration,
+		Attempts:      r.stats.attempts,
+		RequestCharge: r.stats.requestCharge,
+		ActivityID:    r.stats.activityID,
+		RequestID:     r.requestID(),
+		Err:           err,
+	})
+}
+
+// Report a request to the configured observer
+func (c *Client) observeRequest(r *Request, resp *http.Response, err error, duration time.Duration) {
+	observer := c.Config.MetricsObserver
+	if observer == nil {
+		return
+	}
+	m := RequestMetrics{
+		Operation:         r.operation(),
+		ResourceType:      r.rType,
+		Endpoint:          r.URL.Host,
+		Duration:          duration,
+		RequestID:         r.requestID(),
+		DiagnosticContext: r.diagnosticContext,
+		Err:               err,
+	}
+	if resp != nil {
+		m.StatusCode = resp.StatusCode
+		m.RequestCharge = (&Response{Header: resp.Header}).RequestCharge()
+		m.ActivityID = resp.Header.Get(HeaderActivityID)
+	}
+	observer.ObserveRequest(m)
+}
+
+// Report a retry of the request to the configured observer
+func (c *Client) observeRetry(r *Request, reason string) {
+	if observer := c.Config.MetricsObserver; observer != nil {
+		observer.ObserveRetry(r.operation(), reason)
+	}
+}
+
+// Report a retry to the configured observer
+func (c *DocumentDB) observeRetry(operation, reason string) {
+	if c.config != nil && c.config.MetricsObserver != nil {
+		c.config.MetricsObserver.ObserveRetry(operation, reason)
+	}
+}
+
+// Name the operation performed by the request
+func (req *Request) operation() string {
+	switch req.Method {
+	case http.MethodGet:
+		return "Read"
+	case http.MethodDelete:
+		return "Delete"
+	case http.MethodPut:
+		return "Replace"
+	case http.MethodPatch:
+		return "Patch"
+	case http.MethodPost:
+		switch {
+		case req.Header.Get(HeaderIsQuery) == "true":
+			return "Query"
+		case req.Header.Get(HeaderUpsert) == "true":
+			return "Upsert"
+		case strings.HasSuffix(strings.TrimSuffix(req.URL.Path, "/"), "/"+req.rType):
+			return "Create"
+		}
+		return "Execute"
+	}
+	return req.Method
+}
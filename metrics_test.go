@@ -0,0 +1,145 @@
+package documentdb
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type ObserverRecorder struct {
+	sync.Mutex
+	Requests []RequestMetrics
+	Retries  []string
+}
+
+func (o *ObserverRecorder) ObserveRequest(m RequestMetrics) {
+	o.Lock()
+	defer o.Unlock()
+	o.Requests = append(o.Requests, m)
+}
+
+func (o *ObserverRecorder) ObserveRetry(operation, reason string) {
+	o.Lock()
+	defer o.Unlock()
+	o.Retries = append(o.Retries, operation+":"+reason)
+}
+
+func TestMetricsObserver(t *testing.T) {
+	assert := assert.New(t)
+	s := ServerFactory(`{}`, `{}`, 500)
+	s.SetStatus(http.StatusOK)
+	defer s.Close()
+	observer := &ObserverRecorder{}
+	config := NewConfig(&Key{Key: "YXJpZWwNCg=="})
+	config.MetricsObserver = observer
+	client := &Client{Url: s.URL, Config: config}
+
+	client.Read("dbs/b7NTAS==/", &Database{})
+	client.Query("dbs", NewQuery("SELECT * FROM ROOT r"), &Database{})
+	client.Read("dbs/b7NTAS==/", &Database{})
+
+	if assert.Len(observer.Requests, 3) {
+		assert.Equal("Read", observer.Requests[0].Operation)
+		assert.Equal("dbs", observer.Requests[0].ResourceType)
+		assert.Equal(http.StatusOK, observer.Requests[0].StatusCode)
+		assert.Equal(s.Listener.Addr().String(), observer.Requests[0].Endpoint)
+		assert.Equal("Query", observer.Requests[1].Operation)
+		assert.Equal(http.StatusInternalServerError, observer.Requests[2].StatusCode)
+	}
+}
+
+func TestMetricsObserverTransportError(t *testing.T) {
+	observer := &ObserverRecorder{}
+	config := NewConfig(&Key{Key: "YXJpZWwNCg=="})
+	config.MetricsObserver = observer
+	config.RetryOptions = RetryOptions{MaxRetries: 1, InitialBackoff: time.Millisecond}
+	client := &Client{Url: "http://127.0.0.1:1", Config: config}
+
+	_, err := client.Delete("dbs/b7NTAS==/")
+	assert.Error(t, err)
+	if assert.Len(t, observer.Requests, 2) {
+		assert.Equal(t, "Delete", observer.Requests[0].Operation)
+		assert.Equal(t, 0, observer.Requests[0].StatusCode)
+		assert.Error(t, observer.Requests[0].Err)
+	}
+	assert.Equal(t, []string{"Delete:" + RetryReasonTransient}, observer.Retries)
+}
+
+func TestMetricsObserverBulkRetries(t *testing.T) {
+	throttled := false
+	s, c := BulkServerFactory(func(w http.ResponseWriter, r *http.Request) {
+		if !throttled {
+			throttled = true
+			http.Error(w, `{"code": "429", "message": "Request rate is large"}`, http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{}`))
+	})
+	defer s.Close()
+	observer := &ObserverRecorder{}
+	c.config.MetricsObserver = observer
+
+	_, err := c.BulkUpsert(context.Background(), "coll/", []interface{}{&Document{}}, &BulkOptions{Backoff: time.Millisecond})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Upsert:throttled"}, observer.Retries)
+	assert.Len(t, observer.Requests, 2)
+}
+
+func TestRequestOperation(t *testing.T) {
+	operation := func(method, link string, opts ...CallOption) string {
+		r, _ := http.NewRequest(method, "https://localhost/"+link, nil)
+		req := ResourceRequest(link, r)
+		for _, opt := range opts {
+			opt(req)
+		}
+		return req.operation()
+	}
+	assert.Equal(t, "Create", operation(http.MethodPost, "dbs"))
+	assert.Equal(t, "Create", operation(http.MethodPost, "dbs/b5NCAA==/colls/b5NCAIu9NwA=/sprocs/"))
+	assert.Equal(t, "Execute", operation(http.MethodPost, "dbs/b5NCAA==/colls/b5NCAIu9NwA=/sprocs/b5NCAIu9NwABAAAAAAAAgA=="))
+	assert.Equal(t, "Upsert", operation(http.MethodPost, "dbs/b5NCAA==/colls/b5NCAIu9NwA=/docs/", Upsert()))
+	assert.Equal(t, "Replace", operation(http.MethodPut, "dbs/b5NCAA==/"))
+	assert.Equal(t, "Patch", operation(http.MethodPatch, "dbs/b5NCAA==/colls/b5NCAIu9NwA=/docs/b5NCAIu9NwABAAAAAAAAAA==/"))
+}
+
+type CallObserverRecorder struct {
+	ObserverRecorder
+	Calls []CallMetrics
+}
+
+func (o *CallObserverRecorder) ObserveCall(m CallMetrics) {
+	o.Lock()
+	defer o.Unlock()
+	o.Calls = append(o.Calls, m)
+}
+
+func TestCallObserver(t *testing.T) {
+	assert := assert.New(t)
+	s, client, _ := RetryServerFactory(http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusServiceUnavailable)
+	defer s.Close()
+	observer := &CallObserverRecorder{}
+	client.Config.MetricsObserver = observer
+
+	_, err := client.Read("dbs/db/colls/coll/docs/1", nil)
+	assert.Error(err)
+	_, err = client.Read("dbs/db/colls/coll/docs/1", nil)
+	assert.NoError(err)
+
+	assert.Len(observer.Requests, 6)
+	if assert.Len(observer.Calls, 2) {
+		failed, succeeded := observer.Calls[0], observer.Calls[1]
+		assert.Equal("Read", failed.Operation)
+		assert.Equal("docs", failed.ResourceType)
+		assert.Equal(http.StatusServiceUnavailable, failed.StatusCode, "should report the final status once the retries are exhausted")
+		assert.Equal(4, failed.Attempts)
+		assert.Error(failed.Err)
+		assert.Equal(http.StatusOK, succeeded.StatusCode)
+		assert.Equal(2, succeeded.Attempts)
+		assert.NoError(succeeded.Err)
+	}
+}
@@ -1,5 +1,7 @@
 package documentdb
 
+import "fmt"
+
 // Resource
 type Resource struct {
 	Id   string `json:"id,omitempty"`
@@ -12,10 +14,76 @@ type Resource struct {
 // Indexing policy
 // TODO: Ex/IncludePaths
 type IndexingPolicy struct {
-	IndexingMode string `json: "indexingMode,omitempty"`
-	Automatic    bool   `json: "automatic,omitempty"`
+	IndexingMode string `json:"indexingMode,omitempty"`
+	Automatic    bool   `json:"automatic,omitempty"`
+}
+
+// Partition key definition of a collection
+type PartitionKeyDefinition struct {
+	Paths   []string `json:"paths,omitempty"`
+	Kind    string   `json:"kind,omitempty"`
+	Version int      `json:"version,omitempty"`
+}
+
+// Unique key policy of a collection, every unique key is enforced within each logical partition
+type UniqueKeyPolicy struct {
+	UniqueKeys []UniqueKey `json:"uniqueKeys,omitempty"`
+}
+
+// Unique key of a collection, the combination of its paths values is unique within a logical partition
+type UniqueKey struct {
+	Paths []string `json:"paths,omitempty"`
+}
+
+// Special values of Collection.AnalyticalStorageTTL
+const (
+	// AnalyticalStorageTTLInfinite keeps the documents in the analytical store forever
+	AnalyticalStorageTTLInfinite = -1
+
+	// AnalyticalStorageTTLOff disables the analytical store
+	AnalyticalStorageTTLOff = 0
+)
+
+// Geospatial types of a collection, deciding how spatial data is interpreted
+const (
+	// GeospatialGeography interprets coordinates on a round earth(WGS-84), the default
+	GeospatialGeography = "Geography"
+
+	// GeospatialGeometry interprets coordinates on a flat plane, it requires bounding boxes
+	// on the spatial indexes
+	GeospatialGeometry = "Geometry"
+)
+
+// Geospatial configuration of a collection
+type GeospatialConfig struct {
+	Type string `json:"type,omitempty"`
+}
+
+// Client encryption policy of a collection, the paths encrypted by the clients, see ClientEncryptionPolicy.FieldCodecs
+type ClientEncryptionPolicy struct {
+	IncludedPaths       []ClientEncryptionIncludedPath `json:"includedPaths"`
+	PolicyFormatVersion int                            `json:"policyFormatVersion,omitempty"`
 }
 
+// An encrypted path of a client encryption policy
+type ClientEncryptionIncludedPath struct {
+	Path                  string `json:"path"`
+	ClientEncryptionKeyID string `json:"clientEncryptionKeyId"`
+	// EncryptionType is EncryptionTypeDeterministic or EncryptionTypeRandomized
+	EncryptionType      string `json:"encryptionType"`
+	EncryptionAlgorithm string `json:"encryptionAlgorithm"`
+}
+
+const (
+	// EncryptionTypeDeterministic encrypts a value to the same cipher text every time
+	EncryptionTypeDeterministic = "Deterministic"
+	// EncryptionTypeRandomized encrypts a value to a different cipher text every time
+	EncryptionTypeRandomized = "Randomized"
+
+	// EncryptionAlgorithmAEAD is the encryption algorithm of client encryption policies
+	EncryptionAlgorithmAEAD = "AEAD_AES_256_CBC_HMAC_SHA256"
+)
+
 // Database
 type Database struct {
 	Resource
@@ -37,12 +105,25 @@ func (d Databases) First() *Database {
 // Collection
 type Collection struct {
 	Resource
-	IndexingPolicy IndexingPolicy `json:"indexingPolicy,omitempty"`
-	Docs           string         `json:"_docs,omitempty"`
-	Udf            string         `json:"_udfs,omitempty"`
-	Sporcs         string         `json:"_sporcs,omitempty"`
-	Triggers       string         `json:"_triggers,omitempty"`
-	Conflicts      string         `json:"_conflicts,omitempty"`
+	IndexingPolicy IndexingPolicy          `json:"indexingPolicy,omitempty"`
+	PartitionKey   *PartitionKeyDefinition `json:"partitionKey,omitempty"`
+	// UniqueKeyPolicy is nil for collections without unique keys, see ReadDocumentByUniqueKey
+	UniqueKeyPolicy *UniqueKeyPolicy `json:"uniqueKeyPolicy,omitempty"`
+	// GeospatialConfig is nil for collections using the default(Geography)
+	GeospatialConfig *GeospatialConfig `json:"geospatialConfig,omitempty"`
+	// DefaultTTL is the time to live in seconds of the documents that don't set their own "ttl".
+	// Nil disables expiry, -1 enables it without expiring documents by default.
+	DefaultTTL *int `json:"defaultTtl,omitempty"`
+	// AnalyticalStorageTTL is the time to live in seconds of the documents in the analytical store
+	// (Synapse Link), AnalyticalStorageTTLInfinite or AnalyticalStorageTTLOff. Nil leaves it unset.
+	AnalyticalStorageTTL *int `json:"analyticalStorageTtl,omitempty"`
+	// ClientEncryptionPolicy is nil for collections without client side encryption
+	ClientEncryptionPolicy *ClientEncryptionPolicy `json:"clientEncryptionPolicy,omitempty"`
+	Docs                   string                  `json:"_docs,omitempty"`
+	Udf                    string                  `json:"_udfs,omitempty"`
+	Sporcs                 string                  `json:"_sporcs,omitempty"`
+	Triggers               string                  `json:"_triggers,omitempty"`
+	Conflicts              string                  `json:"_conflicts,omitempty"`
 }
 
 // Collection slice of Collection elements
@@ -56,10 +137,35 @@ func (c Collections) First() *Collection {
 	return &c[0]
 }
 
+// Check the typed collection bodies before they are sent, other bodies are sent as is
+func validateCollection(body interface{}) error {
+	var coll *Collection
+	switch t := body.(type) {
+	case *Collection:
+		coll = t
+	case Collection:
+		coll = &t
+	}
+	if coll == nil {
+		return nil
+	}
+	if ttl := coll.AnalyticalStorageTTL; ttl != nil && *ttl < AnalyticalStorageTTLInfinite {
+		return fmt.Errorf("analytical storage TTL %d is invalid, must be -1(infinite), 0(off) or a number of seconds", *ttl)
+	}
+	if coll.GeospatialConfig == nil {
+		return nil
+	}
+	switch coll.GeospatialConfig.Type {
+	case GeospatialGeography, GeospatialGeometry:
+		return nil
+	}
+	return fmt.Errorf("geospatial type %q is invalid, must be %s or %s", coll.GeospatialConfig.Type, GeospatialGeography, GeospatialGeometry)
+}
+
 // Document
 type Document struct {
 	Resource
-	attachments string `json:"attachments,omitempty"`
+	Attachments string `json:"_attachments,omitempty"`
 }
 
 // Stored Procedure
@@ -80,4 +186,6 @@ type PartitionKeyRange struct {
 	PartitionKeyRangeID string `json:"id,omitempty"`
 	MinInclusive        string `json:"minInclusive,omitempty"`
 	MaxInclusive        string `json:"maxExclusive,omitempty"`
+	// Parents holds the ids of the ranges this range was split from
+	Parents []string `json:"parents,omitempty"`
 }
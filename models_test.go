@@ -0,0 +1,28 @@
+package documentdb
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIndexingPolicyJSON(t *testing.T) {
+	b, err := json.Marshal(IndexingPolicy{IndexingMode: "consistent", Automatic: true})
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"indexingMode": "consistent", "automatic": true}`, string(b))
+
+	var policy IndexingPolicy
+	assert.Nil(t, json.Unmarshal([]byte(`{"indexingMode": "lazy", "automatic": false}`), &policy))
+	assert.Equal(t, IndexingPolicy{IndexingMode: "lazy"}, policy)
+}
+
+func TestDocumentAttachmentsJSON(t *testing.T) {
+	var doc Document
+	assert.Nil(t, json.Unmarshal([]byte(`{"id": "1", "_attachments": "attachments/"}`), &doc))
+	assert.Equal(t, "attachments/", doc.Attachments)
+
+	b, err := json.Marshal(Document{Resource: Resource{Id: "1"}})
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"id": "1"}`, string(b))
+}
@@ -0,0 +1,145 @@
+package documentdb
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+var (
+	// ErrSharedThroughput is returned when the throughput of a collection is requested, but the
+	// collection has no dedicated throughput and shares the throughput of its database instead
+	ErrSharedThroughput = errors.New("collection shares the throughput of its database, read the database throughput instead")
+
+	// ErrNoThroughput is returned when the throughput of a database is requested, but the database
+	// has no shared throughput and its collections are provisioned one by one
+	ErrNoThroughput = errors.New("database has no shared throughput, read the throughput of its collections instead")
+
+	// ErrAutoscaleThroughput is returned when the throughput of a resource provisioned with autoscale
+	// is replaced, its maximum throughput must be changed instead
+	ErrAutoscaleThroughput = errors.New("throughput is provisioned with autoscale, replace its maximum throughput instead")
+)
+
+// Offer holds the provisioned throughput of a database(shared throughput) or a collection(dedicated throughput)
+type Offer struct {
+	Resource
+	OfferVersion string       `json:"offerVersion,omitempty"`
+	OfferType    string       `json:"offerType,omitempty"`
+	Content      OfferContent `json:"content"`
+	// ResourceLink is the self link of the database or collection the offer applies to
+	ResourceLink string `json:"resource,omitempty"`
+	// OfferResourceId is the resource id of the database or collection the offer applies to
+	OfferResourceId string `json:"offerResourceId,omitempty"`
+}
+
+// Offer content
+type OfferContent struct {
+	// OfferThroughput is the provisioned throughput in request units per second
+	OfferThroughput int `json:"offerThroughput"`
+	// OfferAutopilotSettings is set when the throughput is provisioned with autoscale
+	OfferAutopilotSettings *OfferAutopilotSettings `json:"offerAutopilotSettings,omitempty"`
+}
+
+// OfferAutopilotSettings holds the autoscale settings of an offer
+type OfferAutopilotSettings struct {
+	// MaxThroughput is the throughput in request units per second the resource scales up to
+	MaxThroughput int `json:"maxThroughput"`
+}
+
+// Read all offers of the account that satisfy a query
+func (c *DocumentDB) QueryOffers(query *Query, opts ...CallOption) (offers []Offer, err error) {
+	data := struct {
+		Offers []Offer `json:"Offers,omitempty"`
+		Count  int     `json:"_count,omitempty"`
+	}{}
+	if query != nil {
+		_, err = c.client.Query("offers", query, &data, opts...)
+	} else {
+		_, err = c.client.Read("offers", &data, opts...)
+	}
+	if offers = data.Offers; err != nil {
+		offers = nil
+	}
+	return
+}
+
+// Read the offer of a database or a collection by its link.
+// It fails with ErrSharedThroughput for a collection that shares the throughput of its
+// database, and with ErrNoThroughput for a database without shared throughput.
+// The options apply to reading the resource, not to the offers query.
+func (c *DocumentDB) ReadOffer(link string, opts ...CallOption) (*Offer, error) {
+	offer, _, err := c.readOffer(link, opts)
+	return offer, err
+}
+
+// Read the offer of a resource, along with its raw json
+func (c *DocumentDB) readOffer(link string, opts []CallOption) (*Offer, json.RawMessage, error) {
+	var resource Resource
+	if _, err := c.client.Read(link, &resource, opts...); err != nil {
+		return nil, nil, err
+	}
+	data := struct {
+		Offers []json.RawMessage `json:"Offers,omitempty"`
+	}{}
+	query := NewQuery("SELECT * FROM root r WHERE r.offerResourceId = @rid", P{"@rid", resource.Rid})
+	if _, err := c.client.Query("offers", query, &data); err != nil {
+		return nil, nil, err
+	}
+	if len(data.Offers) == 0 {
+		if _, rType := parse(link); rType == "colls" {
+			return nil, nil, ErrSharedThroughput
+		}
+		return nil, nil, ErrNoThroughput
+	}
+	var offer *Offer
+	if err := Serialization.Unmarshal(data.Offers[0], &offer); err != nil {
+		return nil, nil, err
+	}
+	return offer, data.Offers[0], nil
+}
+
+// Read the provisioned throughput(RU/s) of a database or a collection by its link, see ReadOffer
+func (c *DocumentDB) ReadThroughput(link string, opts ...CallOption) (int, error) {
+	offer, err := c.ReadOffer(link, opts...)
+	if err != nil {
+		return 0, err
+	}
+	return offer.Content.OfferThroughput, nil
+}
+
+// Replace the provisioned throughput(RU/s) of a database or a collection by its link, see ReadOffer.
+// The other properties of the offer are kept as read. It fails with ErrAutoscaleThroughput for the
+// resources provisioned with autoscale.
+func (c *DocumentDB) ReplaceThroughput(link string, throughput int, opts ...CallOption) (*Offer, error) {
+	offer, raw, err := c.readOffer(link, opts)
+	if err != nil {
+		return nil, err
+	}
+	if offer.Content.OfferAutopilotSettings != nil {
+		return nil, ErrAutoscaleThroughput
+	}
+	var fields, content map[string]json.RawMessage
+	if err = Serialization.Unmarshal(raw, &fields); err != nil {
+		return nil, err
+	}
+	if err = Serialization.Unmarshal(fields["content"], &content); err != nil {
+		return nil, err
+	}
+	if content == nil {
+		content = make(map[string]json.RawMessage)
+	}
+	if content["offerThroughput"], err = Serialization.Marshal(throughput); err != nil {
+		return nil, err
+	}
+	if fields["content"], err = Serialization.Marshal(content); err != nil {
+		return nil, err
+	}
+	body, err := Serialization.Marshal(fields)
+	if err != nil {
+		return nil, err
+	}
+	var replaced *Offer
+	if _, err = c.client.Replace(offer.Self, body, &replaced); err != nil {
+		return nil, err
+	}
+	return replaced, nil
+}
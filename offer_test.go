@@ -0,0 +1,89 @@
+package documentdb
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Serve a database with shared throughput, holding a collection that shares it and one with its own
+func OfferServerFactory(t *testing.T) (*DocumentDB, func()) {
+	offers := map[string]string{
+		"db_rid":        `{"id": "o1", "_self": "offers/o1/", "offerVersion": "V2", "content": {"offerThroughput": 400}, "offerResourceId": "db_rid"}`,
+		"dedicated_rid": `{"id": "o2", "_self": "offers/o2/", "offerVersion": "V2", "content": {"offerThroughput": 1000, "offerIsRUPerMinuteThroughputEnabled": false}, "offerResourceId": "dedicated_rid"}`,
+		"autoscale_rid": `{"id": "o3", "_self": "offers/o3/", "offerVersion": "V2", "content": {"offerThroughput": 400, "offerAutopilotSettings": {"maxThroughput": 4000}}, "offerResourceId": "autoscale_rid"}`,
+	}
+	s, c := BulkServerFactory(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/dbs/db":
+			fmt.Fprint(w, `{"id": "db", "_rid": "db_rid"}`)
+		case "/dbs/other":
+			fmt.Fprint(w, `{"id": "other", "_rid": "other_rid"}`)
+		case "/dbs/db/colls/shared/":
+			fmt.Fprint(w, `{"id": "shared", "_rid": "shared_rid"}`)
+		case "/dbs/db/colls/dedicated/":
+			fmt.Fprint(w, `{"id": "dedicated", "_rid": "dedicated_rid"}`)
+		case "/dbs/db/colls/autoscale/":
+			fmt.Fprint(w, `{"id": "autoscale", "_rid": "autoscale_rid"}`)
+		case "/offers":
+			assert.Empty(t, r.Header.Get(HeaderPartitionKey), "should not forward the resource options")
+			var q Query
+			json.NewDecoder(r.Body).Decode(&q)
+			if offer, ok := offers[q.Parameters[0].Value.(string)]; ok {
+				fmt.Fprintf(w, `{"Offers": [%s], "_count": 1}`, offer)
+			} else {
+				fmt.Fprint(w, `{"Offers": [], "_count": 0}`)
+			}
+		case "/offers/o2/":
+			assert.Equal(t, http.MethodPut, r.Method)
+			b, _ := ioutil.ReadAll(r.Body)
+			assert.JSONEq(t, `{"id": "o2", "_self": "offers/o2/", "offerVersion": "V2", "offerResourceId": "dedicated_rid",
+				"content": {"offerThroughput": 2000, "offerIsRUPerMinuteThroughputEnabled": false}}`, string(b))
+			w.Write(b)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	return c, s.Close
+}
+
+func TestReadThroughput(t *testing.T) {
+	c, close := OfferServerFactory(t)
+	defer close()
+
+	throughput, err := c.ReadThroughput("dbs/db")
+	assert.NoError(t, err)
+	assert.Equal(t, 400, throughput, "should read the shared throughput of the database")
+
+	throughput, err = c.ReadThroughput("dbs/db/colls/dedicated/")
+	assert.NoError(t, err)
+	assert.Equal(t, 1000, throughput)
+
+	_, err = c.ReadThroughput("dbs/db/colls/shared/")
+	assert.Equal(t, ErrSharedThroughput, err)
+
+	_, err = c.ReadThroughput("dbs/other")
+	assert.Equal(t, ErrNoThroughput, err)
+}
+
+func TestReplaceThroughput(t *testing.T) {
+	c, close := OfferServerFactory(t)
+	defer close()
+
+	offer, err := c.ReplaceThroughput("dbs/db/colls/dedicated/", 2000, PartitionKey("pk"))
+	assert.NoError(t, err)
+	assert.Equal(t, 2000, offer.Content.OfferThroughput)
+
+	_, err = c.ReplaceThroughput("dbs/db/colls/shared/", 2000)
+	assert.Equal(t, ErrSharedThroughput, err)
+
+	_, err = c.ReplaceThroughput("dbs/db/colls/autoscale/", 2000)
+	assert.Equal(t, ErrAutoscaleThroughput, err)
+	offer, err = c.ReadOffer("dbs/db/colls/autoscale/")
+	assert.NoError(t, err)
+	assert.Equal(t, &OfferAutopilotSettings{MaxThroughput: 4000}, offer.Content.OfferAutopilotSettings)
+}
@@ -1,7 +1,9 @@
 package documentdb
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"strconv"
 )
 
@@ -22,9 +24,31 @@ const (
 	Eventual Consistency = "Eventual"
 )
 
+// PriorityLevel type to define request priority levels
+type PriorityLevel string
+
+const (
+	// PriorityLow requests are throttled first when the collection is rate limited
+	PriorityLow PriorityLevel = "Low"
+
+	// PriorityHigh is the default priority level
+	PriorityHigh PriorityLevel = "High"
+)
+
 // CallOption function
 type CallOption func(r *Request) error
 
+// PartitionKeyNone is the partition key of the documents that have no value at the partition key path,
+// e.g: documents written before the collection was partitioned. Pass it to PartitionKey to address them,
+// PartitionKey(nil) addresses the documents whose partition key is null.
+var PartitionKeyNone json.Marshaler = partitionKeyNone{}
+
+type partitionKeyNone struct{}
+
+func (partitionKeyNone) MarshalJSON() ([]byte, error) {
+	return []byte("[{}]"), nil
+}
+
 // PartitionKey specificy which partiotion will be used to satisfty the request
 func PartitionKey(partitionKey interface{}) CallOption {
 
@@ -71,6 +95,19 @@ func Limit(limit int) CallOption {
 	}
 }
 
+// MaxItemCount sets the max number of items of a response page, like Limit, but rejects the
+// ambiguous zero. -1 lets the server return as many items as fit in a page.
+func MaxItemCount(n int) CallOption {
+	header := strconv.Itoa(n)
+	return func(r *Request) error {
+		if n == 0 || n < -1 {
+			return fmt.Errorf("max item count %d is invalid, must be positive or -1", n)
+		}
+		r.Header.Set(HeaderMaxItemCount, header)
+		return nil
+	}
+}
+
 // Continuation a string token returned for queries and read-feed operations if there are more results to be read. Clients can retrieve the next page of results by resubmitting the request with the x-ms-continuation request header set to this value.
 func Continuation(continuation string) CallOption {
 	return func(r *Request) error {
@@ -90,7 +127,7 @@ func ConsistencyLevel(consistency Consistency) CallOption {
 	}
 }
 
-// SessionToken a string token used with session level consistency. For more information, see
+// SessionToken a string token used with session level consistency, see Response.SessionToken and Config.AutoTrackSessionToken. For more information, see
 func SessionToken(sessionToken string) CallOption {
 	return func(r *Request) error {
 		r.Header.Set(HeaderSessionToken, sessionToken)
@@ -98,7 +135,7 @@ func SessionToken(sessionToken string) CallOption {
 	}
 }
 
-// CrossPartition allows query to run on all partitions
+// CrossPartition allows query to run on all partitions, see also QueryDocumentsCrossPartition
 func CrossPartition() CallOption {
 	return func(r *Request) error {
 		r.Header.Set(HeaderCrossPartition, "true")
@@ -108,6 +145,8 @@ func CrossPartition() CallOption {
 
 // IfMatch used to make operation conditional for optimistic concurrency. The value should be the etag value of the resource.
 // (applicable only on PUT and DELETE)
+// A resource modified since the etag was read fails the operation with a 412 error, matching ErrPreconditionFailed:
+// read the resource again and retry the change on the new version.
 func IfMatch(etag string) CallOption {
 	return func(r *Request) error {
 		r.Header.Set(HeaderIfMatch, etag)
@@ -117,6 +156,7 @@ func IfMatch(etag string) CallOption {
 
 // IfNoneMatch makes operation conditional to only execute if the resource has changed. The value should be the etag of the resource.
 // Optional (applicable only on GET)
+// An unchanged resource is reported with an error matching ErrNotModified, keep using the cached copy then.
 func IfNoneMatch(etag string) CallOption {
 	return func(r *Request) error {
 		r.Header.Set(HeaderIfNonMatch, etag)
@@ -148,3 +188,127 @@ func ChangeFeedPartitionRangeID(id string) CallOption {
 		return nil
 	}
 }
+
+// ContinuationExpected declares whether the client is able to follow continuation tokens for a query.
+// When false the gateway must return the whole result in a single response, which is rejected for
+// queries that can't be served that way. The iterators set it to true on every query page request.
+// It's ignored by the requests that aren't queries, e.g: reading the documents feed.
+func ContinuationExpected(expected bool) CallOption {
+	header := strconv.FormatBool(expected)
+	return func(r *Request) error {
+		if r.Header.Get(HeaderIsQuery) != "" {
+			r.Header.Set(HeaderIsContinuationExpected, header)
+		}
+		return nil
+	}
+}
+
+// Priority sets the request priority level. When the collection is rate limited, low priority requests
+// are throttled before high priority ones. Requests are high priority by default, but the bulk, import,
+// export and copy helpers default to PriorityLow: pass Priority(PriorityHigh) to them to opt out.
+func Priority(level PriorityLevel) CallOption {
+	return func(r *Request) error {
+		r.Header.Set(HeaderPriorityLevel, string(level))
+		return nil
+	}
+}
+
+// ThroughputBucket assigns the request to a throughput bucket(1-5), limiting the share of the
+// provisioned throughput it can consume, e.g: to keep background jobs from starving the main workload.
+// Buckets are orthogonal to priority levels: a bucket caps the request units a workload may use, while
+// the priority decides which requests are throttled first once the collection is rate limited.
+func ThroughputBucket(bucket int) CallOption {
+	return func(r *Request) error {
+		if bucket < 1 || bucket > 5 {
+			return fmt.Errorf("throughput bucket %d is invalid, must be between 1 and 5", bucket)
+		}
+		r.Header.Set(HeaderThroughputBucket, strconv.Itoa(bucket))
+		return nil
+	}
+}
+
+// Idempotent overrides the retry classification of the request operation(see RetryOptions),
+// e.g: Idempotent(true) for a stored procedure that is safe to run twice, or Idempotent(false)
+// for a Replace that must not be repeated
+func Idempotent(idempotent bool) CallOption {
+	return func(r *Request) error {
+		r.idempotent = &idempotent
+		return nil
+	}
+}
+
+// VerifyCreate makes a document Create safe to retry after failures that leave unknown whether it
+// was applied: network errors, 408(Request Timeout) and 503(Service Unavailable). Before retrying,
+// the client reads the document back by id, and returns it instead of creating it twice if the failed
+// attempt went through. The retries follow Config.RetryOptions.
+//
+// The document must carry a deterministic id(e.g: set by IdentificationHydrator before the first attempt),
+// every verification costs the request units of a point read. The verification is best-effort under
+// consistency levels weaker than strong, where the read may miss a write that was just applied.
+func VerifyCreate() CallOption {
+	return func(r *Request) error {
+		r.verifyCreate = true
+		return nil
+	}
+}
+
+// WithContext sets the context of the request: cancelling it aborts the request in flight and stops
+// its retries(see RetryOptions), the request then fails with the context error.
+// The helpers accepting a context(e.g: BulkCreate) set it on every request they send.
+func WithContext(ctx context.Context) CallOption {
+	return func(r *Request) error {
+		r.Request = r.Request.WithContext(ctx)
+		return nil
+	}
+}
+
+// WithMasterKey signs the request with the given key instead of Config.MasterKey, e.g: to use the
+// read-only key of the account for some calls, or the secondary key while the primary one is rotated.
+// A nil key keeps Config.MasterKey. The request is still sent to the client Url: use a client per
+// account to work with several accounts.
+//
+// The key grants access to the whole account: get it from your secret store rather than from
+// the input of the call, and don't log the options holding it. Reuse the same *Key for a given
+// key, it caches the decoded key.
+func WithMasterKey(key *Key) CallOption {
+	return func(r *Request) error {
+		r.key = key
+		return nil
+	}
+}
+
+// ResourceToken authorizes the request with a resource token(the token of a permission) instead of
+// the master key or Config.Credential, e.g: to serve several tenants with a single client, each with
+// the least privileges. The token is sent as given: pass it url-encoded, as the Authorization header
+// requires, e.g: url.QueryEscape("type=resource&ver=1&sig=..."). An empty token keeps the default
+// authorization.
+func ResourceToken(token string) CallOption {
+	return func(r *Request) error {
+		r.resourceToken = token
+		return nil
+	}
+}
+
+// DecodeWith decodes the response of the call with the given driver, instead of Serialization and
+// Config.FallbackSerialization, e.g: for the few collections holding documents of a legacy format.
+// Requests are still encoded with Serialization.
+func DecodeWith(driver *SerializationDriver) CallOption {
+	return func(r *Request) error {
+		r.decoder = driver
+		return nil
+	}
+}
+
+// Prepend low priority to the given options, so it can still be overridden by them
+func lowPriority(opts []CallOption) []CallOption {
+	return append([]CallOption{Priority(PriorityLow)}, opts...)
+}
+
+// ContentType overrides the content type of the request body. Every operation already sends the
+// content type it requires, use it for operations this package doesn't know about yet.
+func ContentType(contentType string) CallOption {
+	return func(r *Request) error {
+		r.Header.Set(HeaderContentType, contentType)
+		return nil
+	}
+}
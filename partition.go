@@ -0,0 +1,134 @@
+package documentdb
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const partitionKeyDeletePath = "operations/partitionkeydelete"
+
+// ErrNotPartitioned is returned when the partition key definition of a collection is requested
+// but the collection isn't partitioned
+var ErrNotPartitioned = errors.New("collection isn't partitioned")
+
+// Return the metadata cache, or nil when caching is disabled
+func (c *DocumentDB) metadataCache() MetadataCache {
+	if c.config == nil {
+		return nil
+	}
+	return c.config.MetadataCache
+}
+
+// ReadPartitionKeyDefinition reads the partition key definition of a collection by self link.
+// The definition is served from the metadata cache when possible.
+func (c *DocumentDB) ReadPartitionKeyDefinition(coll string, opts ...CallOption) (PartitionKeyDefinition, error) {
+	cache := c.metadataCache()
+	if cache != nil {
+		if def, ok := cache.PartitionKeyDefinition(coll); ok {
+			return def, nil
+		}
+	}
+	collection, err := c.ReadCollection(coll, opts...)
+	if err != nil {
+		return PartitionKeyDefinition{}, err
+	}
+	if collection == nil || collection.PartitionKey == nil {
+		return PartitionKeyDefinition{}, ErrNotPartitioned
+	}
+	if cache != nil {
+		cache.SetPartitionKeyDefinition(coll, *collection.PartitionKey)
+	}
+	return *collection.PartitionKey, nil
+}
+
+// ReadPartitionKeyRanges reads all the partition key ranges of a collection by self link.
+// Unlike QueryPartitionKeyRanges, the ranges are served from the metadata cache when possible.
+func (c *DocumentDB) ReadPartitionKeyRanges(coll string, opts ...CallOption) ([]PartitionKeyRange, error) {
+	cache := c.metadataCache()
+	if cache != nil {
+		if ranges, ok := cache.PartitionKeyRanges(coll); ok {
+			return ranges, nil
+		}
+	}
+	ranges, err := c.QueryPartitionKeyRanges(coll, nil, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if cache != nil {
+		cache.SetPartitionKeyRanges(coll, ranges)
+	}
+	return ranges, nil
+}
+
+// Drop the cached metadata of a collection, after its topology changed
+func (c *DocumentDB) invalidateMetadata(coll string) {
+	if cache := c.metadataCache(); cache != nil {
+		cache.Invalidate(coll)
+	}
+}
+
+// PartitionDeletion is a handle to a partition key delete running in the background
+type PartitionDeletion struct {
+	db           *DocumentDB
+	coll         string
+	partitionKey interface{}
+	Response     *Response
+}
+
+// DeletePartition deletes all the documents of a logical partition. The deletion runs in the
+// background on the server and is much cheaper than querying and deleting every document,
+// use the returned handle to monitor its progress.
+func (c *DocumentDB) DeletePartition(coll string, partitionKey interface{}, opts ...CallOption) (*PartitionDeletion, error) {
+	opts = append(opts, PartitionKey(partitionKey))
+	resp, err := c.client.Execute(coll+partitionKeyDeletePath, "", nil, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &PartitionDeletion{db: c, coll: coll, partitionKey: partitionKey, Response: resp}, nil
+}
+
+// Remaining returns the number of documents still stored in the partition,
+// the deletion is complete once it reaches zero
+func (d *PartitionDeletion) Remaining(opts ...CallOption) (int, error) {
+	var count []int
+	opts = append(opts, PartitionKey(d.partitionKey))
+	_, err := d.db.QueryDocuments(d.coll, NewQuery("SELECT VALUE COUNT(1) FROM c"), &count, opts...)
+	if err != nil || len(count) == 0 {
+		return 0, err
+	}
+	return count[0], nil
+}
+
+// ValidatePartitionKey extracts the partition key value from a document according to the
+// partition key path of the collection(e.g: "/address/city"), and fails if it's missing or null.
+// Use it before writing a document to get a clear error instead of a server-side 400.
+func ValidatePartitionKey(doc interface{}, path string) (value interface{}, err error) {
+	data, err := stringify(doc)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err = decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	for _, part := range strings.Split(strings.Trim(path, "/"), "/") {
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("partition key %s is missing in document", path)
+		}
+		if value, ok = obj[strings.Trim(part, `"`)]; !ok {
+			return nil, fmt.Errorf("partition key %s is missing in document", path)
+		}
+	}
+	switch value.(type) {
+	case nil:
+		return nil, fmt.Errorf("partition key %s is null in document", path)
+	case map[string]interface{}, []interface{}:
+		return nil, fmt.Errorf("partition key %s must be a string, number or boolean", path)
+	}
+	return value, nil
+}
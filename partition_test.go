@@ -0,0 +1,68 @@
+package documentdb
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeletePartition(t *testing.T) {
+	assert := assert.New(t)
+	s := ServerFactory(`{}`, `{"Documents": [3]}`)
+	s.SetStatus(http.StatusOK)
+	defer s.Close()
+	c := New(s.URL, NewConfig(&Key{Key: "YXJpZWwNCg=="}))
+
+	deletion, err := c.DeletePartition("dbs/b5NCAA==/colls/b5NCAIu9NwA=/", "tenant")
+	assert.Nil(err)
+	assert.Equal(`["tenant"]`, s.Header.Get(HeaderPartitionKey))
+
+	remaining, err := deletion.Remaining()
+	assert.Nil(err)
+	assert.Equal(3, remaining)
+	assert.Equal(`["tenant"]`, s.Header.Get(HeaderPartitionKey))
+	assert.Contains(s.Body, "COUNT(1)")
+}
+
+func TestParsePartitionKeyDelete(t *testing.T) {
+	rId, rType := parse("dbs/b5NCAA==/colls/b5NCAIu9NwA=/" + partitionKeyDeletePath)
+	assert.Equal(t, "b5NCAIu9NwA=", rId)
+	assert.Equal(t, "partitionkey", rType)
+}
+
+func TestValidatePartitionKey(t *testing.T) {
+	type Address struct {
+		City string `json:"city,omitempty"`
+	}
+	type User struct {
+		Document
+		Tenant  *string  `json:"tenant"`
+		Address *Address `json:"address,omitempty"`
+		Age     int      `json:"age"`
+	}
+	tenant := "acme"
+	user := &User{Tenant: &tenant, Address: &Address{City: "Paris"}, Age: 42}
+
+	value, err := ValidatePartitionKey(user, "/tenant")
+	assert.NoError(t, err)
+	assert.Equal(t, "acme", value)
+
+	value, err = ValidatePartitionKey(user, "/address/city")
+	assert.NoError(t, err)
+	assert.Equal(t, "Paris", value)
+
+	value, err = ValidatePartitionKey(`{"age": 12345678901234567}`, "/age")
+	assert.NoError(t, err)
+	assert.Equal(t, json.Number("12345678901234567"), value, "should keep the numeric precision")
+
+	_, err = ValidatePartitionKey(&User{}, "/tenant")
+	assert.EqualError(t, err, "partition key /tenant is null in document")
+
+	_, err = ValidatePartitionKey(&User{}, "/address/city")
+	assert.EqualError(t, err, "partition key /address/city is missing in document")
+
+	_, err = ValidatePartitionKey(user, "/address")
+	assert.EqualError(t, err, "partition key /address must be a string, number or boolean")
+}
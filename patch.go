@@ -0,0 +1,42 @@
+package documentdb
+
+// PatchOperationType is the operation of a PatchOperation
+type PatchOperationType string
+
+const (
+	// PatchAdd sets a property, or inserts an element in an array(use "/-" to append)
+	PatchAdd PatchOperationType = "add"
+
+	// PatchSet sets a property, or an existing array element
+	PatchSet PatchOperationType = "set"
+
+	// PatchReplace sets a property that must exist
+	PatchReplace PatchOperationType = "replace"
+
+	// PatchRemove removes a property or an array element
+	PatchRemove PatchOperationType = "remove"
+
+	// PatchIncrement adds the value to a number property, creating it if it's missing
+	PatchIncrement PatchOperationType = "incr"
+)
+
+// PatchOperation is an operation of a partial document update, see Client.Patch
+type PatchOperation struct {
+	Op PatchOperationType `json:"op"`
+	// Path is the property the operation applies to, e.g: "/address/city" or "/tags/0"
+	Path string `json:"path"`
+	// Value is ignored by PatchRemove
+	Value interface{} `json:"value,omitempty"`
+}
+
+// The body of a patch request
+type patchRequest struct {
+	Operations []PatchOperation `json:"operations"`
+}
+
+// Patch document by self link, applying the operations(up to 10) atomically instead of replacing the
+// whole document. Patch requests are not retried on timeouts(see RetryOptions), since increments and
+// array inserts aren't idempotent: pass Idempotent(true) for operations that are.
+func (c *DocumentDB) PatchDocument(link string, operations []PatchOperation, ret interface{}, opts ...CallOption) (*Response, error) {
+	return c.client.Patch(link, operations, ret, opts...)
+}
@@ -0,0 +1,49 @@
+package documentdb
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Rename the top-level keys found in names of a document, or of every document of a feed page.
+// Nested objects are user data and keep their keys.
+func renameProperties(data []byte, names map[string]string) ([]byte, error) {
+	var envelope map[string]json.RawMessage
+	if err := Serialization.Unmarshal(data, &envelope); err != nil || envelope == nil {
+		return data, nil
+	}
+	if _, ok := envelope["Documents"]; ok {
+		return transformDocuments(data, func(doc json.RawMessage) (json.RawMessage, error) {
+			return renameKeys(doc, names)
+		})
+	}
+	return renameKeys(data, names)
+}
+
+// Rename the keys of a json object, failing if a renamed key collides with another one
+func renameKeys(data []byte, names map[string]string) ([]byte, error) {
+	var obj map[string]json.RawMessage
+	if err := Serialization.Unmarshal(data, &obj); err != nil || obj == nil {
+		return data, nil
+	}
+	renamed := make(map[string]json.RawMessage, len(obj))
+	for k, v := range obj {
+		if name, ok := names[k]; ok {
+			k = name
+		}
+		if _, ok := renamed[k]; ok {
+			return nil, fmt.Errorf("property %q is set twice after renaming the system properties", k)
+		}
+		renamed[k] = v
+	}
+	return Serialization.Marshal(renamed)
+}
+
+// Swap the keys and values of the system property names mapping
+func invertNames(names map[string]string) map[string]string {
+	inverted := make(map[string]string, len(names))
+	for k, v := range names {
+		inverted[v] = k
+	}
+	return inverted
+}
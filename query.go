@@ -1,17 +1,41 @@
 package documentdb
 
-type Parameter struct {
-	Name  string `json:"name"`
-	Value string `json:"value"`
+// QueryParameter is a named parameter of a query, e.g: @name. The value is sent as a json value
+// apart from the query text, so it's never interpreted as SQL.
+type QueryParameter struct {
+	Name  string      `json:"name"`
+	Value interface{} `json:"value"`
 }
 
-type P = Parameter
+// Parameter is the former name of QueryParameter
+type Parameter = QueryParameter
+
+type P = QueryParameter
 
 type Query struct {
-	Query      string      `json:"query"`
-	Parameters []Parameter `json:"parameters,omitempty"`
+	Query string `json:"query"`
+	// Parameters are omitted from the request body when empty
+	Parameters []QueryParameter `json:"parameters,omitempty"`
 }
 
-func NewQuery(query string, parameters ...Parameter) *Query {
+func NewQuery(query string, parameters ...QueryParameter) *Query {
 	return &Query{query, parameters}
 }
+
+// WithParam adds a parameter to the query, e.g:
+//
+//	NewQuery("SELECT * FROM root r WHERE r.age > @age").WithParam("@age", 18)
+func (q *Query) WithParam(name string, value interface{}) *Query {
+	q.Parameters = append(q.Parameters, QueryParameter{name, value})
+	return q
+}
+
+// Copy the query with its parameter values written according to the encoding, e.g: Time
+// parameters as Unix timestamps to compare them with "_ts"
+func (q *Query) encodeParameters(enc valueEncoding) *Query {
+	encoded := &Query{Query: q.Query, Parameters: make([]QueryParameter, len(q.Parameters))}
+	for i, p := range q.Parameters {
+		encoded.Parameters[i] = QueryParameter{p.Name, applyValueEncoding(p.Value, enc)}
+	}
+	return encoded
+}
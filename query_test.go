@@ -0,0 +1,27 @@
+package documentdb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryParameters(t *testing.T) {
+	b, err := Serialization.Marshal(NewQuery("SELECT * FROM root r"))
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"query": "SELECT * FROM root r"}`, string(b), "empty parameters should be omitted")
+
+	q := NewQuery("SELECT * FROM root r WHERE r.name = @name", P{"@name", "john"}).
+		WithParam("@age", 18).
+		WithParam("@tags", []string{"a", "b"})
+	b, err = Serialization.Marshal(q)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{
+		"query": "SELECT * FROM root r WHERE r.name = @name",
+		"parameters": [
+			{"name": "@name", "value": "john"},
+			{"name": "@age", "value": 18},
+			{"name": "@tags", "value": ["a", "b"]}
+		]
+	}`, string(b))
+}
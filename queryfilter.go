@@ -0,0 +1,152 @@
+package documentdb
+
+import (
+	"encoding/json"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	// The alias of the collection the query selects from and its WHERE clause, once the string literals are
+	// masked(see maskStringLiterals), e.g: "r" for "SELECT * FROM root r WHERE ...". Queries selecting from a
+	// path of the documents(e.g: FROM c.children ch) don't match
+	fromWhere = regexp.MustCompile(`(?is)\bFROM\s+([A-Za-z_]\w*)(?:\s+(?:AS\s+)?([A-Za-z_]\w*))?\s+WHERE\s+(.*)`)
+	// The clauses following the WHERE clause
+	trailingClauses = regexp.MustCompile(`(?i)\b(?:ORDER\s+BY|GROUP\s+BY|OFFSET)\b`)
+	// The conjunctions of a WHERE clause
+	conjunction = regexp.MustCompile(`(?i)\bAND\b`)
+	// A property compared for equality with a literal or a parameter, e.g: c.city = 'Paris' or c["city"] = @city,
+	// making up a whole operand of a conjunction
+	equalityFilter = regexp.MustCompile(`^([A-Za-z_]\w*)((?:\.[A-Za-z_]\w*|\["[^"]+"\])+)\s*=\s*(@\w+|\$\d+|-?\d+(?:\.\d+)?(?:[eE][+-]?\d+)?|true|false|null)$`)
+	// Keywords of filters that may match documents the equalities don't
+	widenedFilter = regexp.MustCompile(`(?i)\b(OR|NOT|JOIN|EXISTS|IN)\b`)
+	// The accessors of a property path, e.g: .address and ["zip"]
+	propertyAccessor = regexp.MustCompile(`\.(\w+)|\["([^"]+)"\]`)
+)
+
+// PartitionKey returns the partition key value the query filters on, for the partition key of the given
+// definition, e.g: "Paris" for "SELECT * FROM c WHERE c.city = 'Paris' AND c.age > 30" partitioned by "/city".
+// Pass it to the PartitionKey option to run the query on a single partition instead of fanning it out.
+//
+// The detection is conservative: the bool result is false unless the WHERE clause is a conjunction requiring
+// the partition key of the documents(the alias of the collection in the FROM clause) to equal a literal or a
+// parameter, without OR, NOT, IN, EXISTS, JOIN, subqueries or expressions around them(e.g: c.city = 'a' || 'b').
+// Only collections partitioned by a single path are supported.
+func (q *Query) PartitionKey(def PartitionKeyDefinition) (interface{}, bool) {
+	if len(def.Paths) != 1 {
+		return nil, false
+	}
+	value, ok := q.equalityFilters()[strings.Trim(def.Paths[0], "/")]
+	return value, ok
+}
+
+// Return the values the WHERE clause of the query requires properties to equal, by property path
+// without its leading slash(e.g: "address/zip"), none if the filter may match other values
+func (q *Query) equalityFilters() map[string]interface{} {
+	if q == nil {
+		return nil
+	}
+	text, literals := maskStringLiterals(q.Query)
+	if strings.Count(strings.ToUpper(text), "SELECT") > 1 || widenedFilter.MatchString(text) {
+		return nil
+	}
+	from := fromWhere.FindStringSubmatch(text)
+	if from == nil {
+		return nil
+	}
+	alias, where := from[1], from[3]
+	if from[2] != "" {
+		alias = from[2]
+	}
+	if loc := trailingClauses.FindStringIndex(where); loc != nil {
+		where = where[:loc[0]]
+	}
+	filters := make(map[string]interface{})
+	for _, operand := range conjunction.Split(where, -1) {
+		// Parentheses grouping the conjunction, e.g: (c.city = 'Paris') AND (c.age > 30)
+		operand = strings.TrimSpace(strings.TrimRight(strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(operand), "(")), ")"))
+		match := equalityFilter.FindStringSubmatch(operand)
+		if match == nil || match[1] != alias {
+			continue
+		}
+		value, ok := q.literal(match[3], literals)
+		if !ok {
+			continue
+		}
+		var path []string
+		for _, accessor := range propertyAccessor.FindAllStringSubmatch(match[2], -1) {
+			path = append(path, accessor[1]+accessor[2])
+		}
+		filters[strings.Join(path, "/")] = value
+	}
+	return filters
+}
+
+// Replace the string literals of a query with placeholders($0, $1...), so the text inside them isn't taken
+// for filters or keywords, and return the literals by placeholder index. Property accessors(e.g: c["city"])
+// are kept as is.
+func maskStringLiterals(text string) (string, []string) {
+	var (
+		masked   strings.Builder
+		literals []string
+	)
+	for i := 0; i < len(text); i++ {
+		quote := text[i]
+		if quote != '\'' && quote != '"' {
+			masked.WriteByte(quote)
+			continue
+		}
+		end := i + 1
+		for end < len(text) && text[end] != quote {
+			if text[end] == '\\' {
+				end++
+			}
+			end++
+		}
+		if end >= len(text) {
+			// Unterminated, leave it to the server to reject
+			masked.WriteString(text[i:])
+			break
+		}
+		literal := text[i : end+1]
+		if quote == '"' && strings.HasSuffix(strings.TrimSpace(text[:i]), "[") && strings.HasPrefix(strings.TrimSpace(text[end+1:]), "]") {
+			masked.WriteString(literal)
+		} else {
+			masked.WriteString("$" + strconv.Itoa(len(literals)))
+			literals = append(literals, literal)
+		}
+		i = end
+	}
+	return masked.String(), literals
+}
+
+// Return the value of a literal, a masked string literal or a parameter of the query
+func (q *Query) literal(token string, literals []string) (interface{}, bool) {
+	if strings.HasPrefix(token, "$") {
+		i, err := strconv.Atoi(token[1:])
+		if err != nil || i >= len(literals) {
+			return nil, false
+		}
+		token = literals[i]
+	}
+	if strings.HasPrefix(token, "@") {
+		for _, p := range q.Parameters {
+			if p.Name == token {
+				return p.Value, true
+			}
+		}
+		return nil, false
+	}
+	if strings.HasPrefix(token, "'") {
+		// Single quoted strings escape quotes like json strings
+		token = `"` + strings.ReplaceAll(strings.ReplaceAll(token[1:len(token)-1], `\'`, `'`), `"`, `\"`) + `"`
+	}
+	var value interface{}
+	decoder := json.NewDecoder(strings.NewReader(token))
+	decoder.UseNumber()
+	if err := decoder.Decode(&value); err != nil || decoder.InputOffset() != int64(len(token)) {
+		return nil, false
+	}
+	return value, true
+}
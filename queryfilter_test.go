@@ -0,0 +1,62 @@
+package documentdb
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryPartitionKey(t *testing.T) {
+	def := PartitionKeyDefinition{Paths: []string{"/city"}, Kind: "Hash", Version: 2}
+	for _, test := range []struct {
+		query *Query
+		pk    interface{}
+		ok    bool
+	}{
+		{NewQuery("SELECT * FROM c WHERE c.city = 'Paris'"), "Paris", true},
+		{NewQuery("SELECT * FROM c WHERE c.age > 30 AND c.city = 'O\\'Neil' ORDER BY c.age"), "O'Neil", true},
+		{NewQuery(`SELECT * FROM root r WHERE r["city"] = @city`, P{"@city", "Rome"}), "Rome", true},
+		{NewQuery("SELECT * FROM c WHERE c.city = 7"), json.Number("7"), true},
+		{NewQuery("SELECT * FROM c WHERE c.city = null"), nil, true},
+		{NewQuery("SELECT * FROM c WHERE c.address.city = 'Paris'"), nil, false},
+		{NewQuery("SELECT * FROM c WHERE c.city = 'Paris' OR c.city = 'Rome'"), nil, false},
+		{NewQuery("SELECT * FROM c WHERE NOT (c.city = 'Paris')"), nil, false},
+		{NewQuery("SELECT * FROM c WHERE c.city IN ('Paris', 'Rome')"), nil, false},
+		{NewQuery("SELECT * FROM c WHERE c.city != 'Paris'"), nil, false},
+		{NewQuery("SELECT * FROM c WHERE c.city >= 'Paris'"), nil, false},
+		{NewQuery("SELECT * FROM c WHERE c.city = @missing"), nil, false},
+		{NewQuery("SELECT * FROM c WHERE c.id IN (SELECT VALUE t FROM t IN c.tags WHERE c.city = 'Paris')"), nil, false},
+		{NewQuery("SELECT * FROM c WHERE CONTAINS(c.note, 'x.city = 5')"), nil, false},
+		{NewQuery(`SELECT * FROM c WHERE c.note = "c.city = 'Rome'" AND c.city = 'Paris'`), "Paris", true},
+		{NewQuery(`SELECT * FROM c WHERE c["city"] = "Paris OR Rome"`), "Paris OR Rome", true},
+		{NewQuery("SELECT * FROM c WHERE (c.city = 'Paris') AND c.age > 30"), "Paris", true},
+		{NewQuery("SELECT * FROM Families AS f WHERE f.city = 'Paris'"), "Paris", true},
+		{NewQuery("SELECT * FROM c.children ch WHERE ch.city = 'Paris'"), nil, false},
+		{NewQuery("SELECT * FROM root r WHERE c.city = 'Paris'"), nil, false},
+		{NewQuery("SELECT * FROM c WHERE c.city = 'Par' || 'is'"), nil, false},
+		{NewQuery("SELECT * FROM c WHERE c.city = 5 + 1"), nil, false},
+		{NewQuery("SELECT * FROM c WHERE 2 * c.city = 4"), nil, false},
+		{NewQuery("SELECT * FROM c WHERE c.city = @a ?? 'x'", P{"@a", nil}), nil, false},
+		{NewQuery("SELECT * FROM c WHERE c.age BETWEEN 1 AND 5 AND c.city = 'Paris'"), "Paris", true},
+		{NewQuery("SELECT * FROM c"), nil, false},
+		{nil, nil, false},
+	} {
+		pk, ok := test.query.PartitionKey(def)
+		name := "nil"
+		if test.query != nil {
+			name = test.query.Query
+		}
+		assert.Equal(t, test.ok, ok, name)
+		assert.Equal(t, test.pk, pk, name)
+	}
+
+	nested := PartitionKeyDefinition{Paths: []string{"/address/city"}, Version: 2}
+	pk, ok := NewQuery("SELECT * FROM c WHERE c.address.city = 'Paris'").PartitionKey(nested)
+	assert.True(t, ok)
+	assert.Equal(t, "Paris", pk)
+
+	hierarchical := PartitionKeyDefinition{Paths: []string{"/tenant", "/city"}, Kind: "MultiHash", Version: 2}
+	_, ok = NewQuery("SELECT * FROM c WHERE c.tenant = 'a' AND c.city = 'Paris'").PartitionKey(hierarchical)
+	assert.False(t, ok, "hierarchical partition keys aren't supported")
+}
@@ -0,0 +1,77 @@
+package documentdb
+
+import (
+	"errors"
+	"net/http"
+)
+
+const (
+	// QueryPlanVersion is the api version required by query plan requests
+	QueryPlanVersion = "2018-12-31"
+
+	// The query features the query plans may report
+	supportedQueryFeatures = "Aggregate, CompositeAggregate, Distinct, GroupBy, MultipleAggregates, MultipleOrderBy, OffsetAndLimit, OrderBy, Top, NonValueAggregate, DCount"
+)
+
+// QueryPlan is the result of ValidateQuery
+type QueryPlan struct {
+	// Valid reports whether the query is valid, Error holds the reason when it isn't
+	Valid bool
+	Error string
+	// Aggregates lists the aggregate functions of the query, e.g: "Count", "Sum"
+	Aggregates []string
+	// GroupBy, Distinct and OrderBy report whether the query uses these clauses
+	GroupBy, Distinct, OrderBy bool
+	// Top, Offset and Limit are the values of these clauses, nil if the query has none
+	Top, Offset, Limit *int
+}
+
+// The query info of a query plan response
+type queryPlanResponse struct {
+	QueryInfo struct {
+		DistinctType       string   `json:"distinctType"`
+		Top                *int     `json:"top"`
+		Offset             *int     `json:"offset"`
+		Limit              *int     `json:"limit"`
+		OrderBy            []string `json:"orderBy"`
+		GroupByExpressions []string `json:"groupByExpressions"`
+		Aggregates         []string `json:"aggregates"`
+	} `json:"queryInfo"`
+}
+
+// ValidateQuery asks the gateway for the plan of a query without running it, e.g: to check a generated
+// query before executing it. A query rejected by the gateway returns a plan that isn't Valid, with the
+// reason in its Error, other failures return an error.
+// The plan request reads no documents and costs no request units.
+func (c *DocumentDB) ValidateQuery(coll string, query *Query, opts ...CallOption) (*QueryPlan, error) {
+	var data queryPlanResponse
+	_, err := c.client.Query(coll+"docs/", query, &data, append(opts[:len(opts):len(opts)], queryPlanRequest)...)
+	var reqErr *RequestError
+	if errors.As(err, &reqErr) && reqErr.StatusCode == http.StatusBadRequest {
+		return &QueryPlan{Error: reqErr.Message}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	info := data.QueryInfo
+	return &QueryPlan{
+		Valid:      true,
+		Aggregates: info.Aggregates,
+		GroupBy:    len(info.GroupByExpressions) > 0,
+		Distinct:   info.DistinctType != "" && info.DistinctType != "None",
+		OrderBy:    len(info.OrderBy) > 0,
+		Top:        info.Top,
+		Offset:     info.Offset,
+		Limit:      info.Limit,
+	}, nil
+}
+
+// Turn a query into a query plan request
+func queryPlanRequest(r *Request) error {
+	r.Header.Set(HeaderIsQueryPlanRequest, "True")
+	r.Header.Set(HeaderSupportedQueryFeatures, supportedQueryFeatures)
+	r.Header.Set(HeaderQueryVersion, "1.0")
+	r.Header.Set(HeaderCrossPartition, "true")
+	r.Header.Set(HeaderVersion, QueryPlanVersion)
+	return nil
+}
@@ -0,0 +1,47 @@
+package documentdb
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateQuery(t *testing.T) {
+	assert := assert.New(t)
+	s, c := BulkServerFactory(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal("True", r.Header.Get(HeaderIsQueryPlanRequest))
+		assert.Equal(QueryPlanVersion, r.Header.Get(HeaderVersion))
+		assert.NotEmpty(r.Header.Get(HeaderSupportedQueryFeatures))
+		assert.Equal("/dbs/db/colls/coll/docs/", r.URL.Path)
+		body, _ := ioutil.ReadAll(r.Body)
+		if strings.Contains(string(body), "SELEC ") {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"code": "BadRequest", "message": "Syntax error, incorrect syntax near 'SELEC'."}`)
+			return
+		}
+		fmt.Fprint(w, `{"partitionedQueryExecutionInfoVersion": 2, "queryInfo": {"distinctType": "Ordered", "top": 10, "offset": null, "limit": null,
+			"orderBy": ["Ascending"], "groupByExpressions": [], "aggregates": []}, "queryRanges": []}`)
+	})
+	defer s.Close()
+
+	plan, err := c.ValidateQuery("dbs/db/colls/coll/", NewQuery("SELECT DISTINCT TOP 10 c.name FROM c ORDER BY c.name"))
+	assert.NoError(err)
+	assert.True(plan.Valid)
+	assert.True(plan.Distinct)
+	assert.True(plan.OrderBy)
+	assert.False(plan.GroupBy)
+	assert.Empty(plan.Aggregates)
+	if assert.NotNil(plan.Top) {
+		assert.Equal(10, *plan.Top)
+	}
+	assert.Nil(plan.Limit)
+
+	plan, err = c.ValidateQuery("dbs/db/colls/coll/", NewQuery("SELEC * FROM c"))
+	assert.NoError(err)
+	assert.False(plan.Valid)
+	assert.Contains(plan.Error, "Syntax error")
+}
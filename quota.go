@@ -0,0 +1,48 @@
+package documentdb
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// SubStatusPartitionKeyQuotaExceeded is returned with 403 when a logical partition reached its
+// maximum size(20GB): writes to the partition fail until documents are deleted from it
+const SubStatusPartitionKeyQuotaExceeded = 1014
+
+// ResourceQuota holds the storage quotas or usage of a collection, in KB for sizes,
+// e.g: {"documentsSize": 10240, "documentsCount": 12, "collectionSize": 10300}
+type ResourceQuota map[string]int64
+
+// Check whether the error reports a storage quota exceeded, see ErrStorageQuotaExceeded
+func isStorageQuotaExceeded(err RequestError) bool {
+	return err.StatusCode == http.StatusForbidden && err.SubStatus == SubStatusPartitionKeyQuotaExceeded
+}
+
+// ResourceQuota returns the storage quotas of the collection, nil if the response doesn't report them
+func (r *Response) ResourceQuota() ResourceQuota {
+	return parseResourceQuota(r.Header.Get(HeaderResourceQuota))
+}
+
+// ResourceUsage returns the storage usage of the collection, nil if the response doesn't report it
+func (r *Response) ResourceUsage() ResourceQuota {
+	return parseResourceQuota(r.Header.Get(HeaderResourceUsage))
+}
+
+// Parse a quota header, e.g: "documentsSize=10240;documentsCount=-1;collectionSize=10240;"
+func parseResourceQuota(header string) ResourceQuota {
+	if header == "" {
+		return nil
+	}
+	quota := make(ResourceQuota)
+	for _, pair := range strings.Split(header, ";") {
+		name, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			continue
+		}
+		if n, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64); err == nil {
+			quota[strings.TrimSpace(name)] = n
+		}
+	}
+	return quota
+}
@@ -0,0 +1,45 @@
+package documentdb
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseResourceQuota(t *testing.T) {
+	assert.Nil(t, parseResourceQuota(""))
+	assert.Equal(t, ResourceQuota{"documentsSize": 10240, "documentsCount": -1, "collectionSize": 10300},
+		parseResourceQuota("documentsSize=10240;documentsCount=-1;collectionSize=10300;"))
+	assert.Equal(t, ResourceQuota{"functions": 25}, parseResourceQuota("functions=25;invalid;size=x"))
+
+	resp := &Response{Header: http.Header{}}
+	resp.Header.Set(HeaderResourceUsage, "documentsSize=12;")
+	assert.Equal(t, ResourceQuota{"documentsSize": 12}, resp.ResourceUsage())
+	assert.Nil(t, resp.ResourceQuota())
+}
+
+func TestStorageQuotaExceeded(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(HeaderSubStatus, strconv.Itoa(SubStatusPartitionKeyQuotaExceeded))
+		w.Header().Set(HeaderResourceQuota, "documentsSize=20971520;")
+		w.Header().Set(HeaderResourceUsage, "documentsSize=20971520;")
+		http.Error(w, `{"code": "Forbidden", "message": "Partition key reached maximum size of 20 GB"}`, http.StatusForbidden)
+	}))
+	defer s.Close()
+	client := &Client{Url: s.URL, Config: NewConfig(&Key{Key: "YXJpZWwNCg=="})}
+
+	_, err := client.Create("dbs/db/colls/coll/docs/", `{"id": "1"}`, nil)
+	assert.True(t, errors.Is(err, ErrStorageQuotaExceeded))
+	assert.False(t, errors.Is(err, ErrTooManyRequests))
+	var reqErr *RequestError
+	assert.True(t, errors.As(err, &reqErr))
+	assert.Equal(t, ResourceQuota{"documentsSize": 20971520}, reqErr.ResourceUsage)
+	assert.Equal(t, ResourceQuota{"documentsSize": 20971520}, reqErr.ResourceQuota)
+
+	assert.False(t, errors.Is(&RequestError{StatusCode: http.StatusForbidden}, ErrStorageQuotaExceeded))
+	assert.False(t, errors.Is(&RequestError{StatusCode: http.StatusTooManyRequests}, ErrStorageQuotaExceeded))
+}
@@ -2,6 +2,7 @@ package documentdb
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
@@ -11,58 +12,191 @@ import (
 )
 
 const (
-	HeaderXDate               = "X-Ms-Date"
-	HeaderAuth                = "Authorization"
-	HeaderVersion             = "X-Ms-Version"
-	HeaderContentType         = "Content-Type"
-	HeaderContentLength       = "Content-Length"
-	HeaderIsQuery             = "X-Ms-Documentdb-Isquery"
-	HeaderUpsert              = "x-ms-documentdb-is-upsert"
-	HeaderPartitionKey        = "x-ms-documentdb-partitionkey"
-	HeaderMaxItemCount        = "x-ms-max-item-count"
-	HeaderContinuation        = "x-ms-continuation"
-	HeaderConsistency         = "x-ms-consistency-level"
-	HeaderSessionToken        = "x-ms-session-token"
-	HeaderCrossPartition      = "x-ms-documentdb-query-enablecrosspartition"
-	HeaderIfMatch             = "If-Match"
-	HeaderIfNonMatch          = "If-None-Match"
-	HeaderIfModifiedSince     = "If-Modified-Since"
-	HeaderActivityID          = "x-ms-activity-id"
-	HeaderRequestCharge       = "x-ms-request-charge"
-	HeaderAIM                 = "A-IM"
-	HeaderPartitionKeyRangeID = "x-ms-documentdb-partitionkeyrangeid"
+	HeaderXDate                  = "X-Ms-Date"
+	HeaderAuth                   = "Authorization"
+	HeaderVersion                = "X-Ms-Version"
+	HeaderContentType            = "Content-Type"
+	HeaderContentLength          = "Content-Length"
+	HeaderIsQuery                = "X-Ms-Documentdb-Isquery"
+	HeaderUpsert                 = "x-ms-documentdb-is-upsert"
+	HeaderPartitionKey           = "x-ms-documentdb-partitionkey"
+	HeaderMaxItemCount           = "x-ms-max-item-count"
+	HeaderContinuation           = "x-ms-continuation"
+	HeaderConsistency            = "x-ms-consistency-level"
+	HeaderSessionToken           = "x-ms-session-token"
+	HeaderCrossPartition         = "x-ms-documentdb-query-enablecrosspartition"
+	HeaderIfMatch                = "If-Match"
+	HeaderIfNonMatch             = "If-None-Match"
+	HeaderETag                   = "etag"
+	HeaderIfModifiedSince        = "If-Modified-Since"
+	HeaderActivityID             = "x-ms-activity-id"
+	HeaderRequestCharge          = "x-ms-request-charge"
+	HeaderAIM                    = "A-IM"
+	HeaderPartitionKeyRangeID    = "x-ms-documentdb-partitionkeyrangeid"
+	HeaderIsContinuationExpected = "x-ms-documentdb-query-iscontinuationexpected"
+	HeaderRetryAfter             = "x-ms-retry-after-ms"
+	HeaderLSN                    = "lsn"
+	HeaderGlobalCommittedLSN     = "x-ms-global-committed-lsn"
+	HeaderQuorumAckedLSN         = "x-ms-quorum-acked-lsn"
+	HeaderSubStatus              = "x-ms-substatus"
+	HeaderIndexTransformation    = "x-ms-documentdb-collection-index-transformation-progress"
+	HeaderPriorityLevel          = "x-ms-cosmos-priority-level"
+	HeaderThroughputBucket       = "x-ms-cosmos-throughput-bucket"
+	HeaderIsBatchRequest         = "x-ms-cosmos-is-batch-request"
+	HeaderBatchAtomic            = "x-ms-cosmos-batch-atomic"
+	HeaderContentPath            = "x-ms-content-path"
+	HeaderAltContentPath         = "x-ms-alt-content-path"
+	HeaderClientRequestID        = "x-ms-client-request-id"
+	HeaderResourceQuota          = "x-ms-resource-quota"
+	HeaderResourceUsage          = "x-ms-resource-usage"
+	HeaderPopulateQueryMetrics   = "x-ms-documentdb-populatequerymetrics"
+	HeaderQueryMetrics           = "x-ms-documentdb-query-metrics"
+	HeaderIsQueryPlanRequest     = "x-ms-cosmos-is-query-plan-request"
+	HeaderSupportedQueryFeatures = "x-ms-cosmos-supported-query-features"
+	HeaderQueryVersion           = "x-ms-cosmos-query-version"
+
+	ContentTypeJSON      = "application/json"
+	ContentTypeQueryJSON = "application/query+json"
+	ContentTypeJSONPatch = "application/json_patch+json"
 
 	SupportedVersion = "2017-02-22"
 )
 
+// ErrPayloadTooLarge matches the errors of requests rejected with 413(Request Entity Too Large),
+// when a document or a batch exceeds the 2MB limit. Check it with errors.Is.
+var ErrPayloadTooLarge = errors.New("request payload is too large")
+
+// Sentinel errors matching the request errors of common status codes, check them with errors.Is,
+// e.g: errors.Is(err, ErrConflict) for a Create of a document that already exists
+var (
+	// ErrNotFound matches 404(Not Found) errors, including expired documents, see ErrExpired
+	ErrNotFound = errors.New("resource not found")
+
+	// ErrConflict matches 409(Conflict) errors
+	ErrConflict = errors.New("resource conflict")
+
+	// ErrPreconditionFailed matches 412(Precondition Failed) errors, e.g: an IfMatch etag that is outdated
+	ErrPreconditionFailed = errors.New("precondition failed")
+
+	// ErrTooManyRequests matches 429(Too Many Requests) errors, of requests throttled beyond RetryOptions
+	ErrTooManyRequests = errors.New("too many requests")
+
+	// ErrTimeout matches 408(Request Timeout) errors
+	ErrTimeout = errors.New("request timeout")
+
+	// ErrNotModified matches 304(Not Modified) errors, of reads with IfNoneMatch whose resource didn't change
+	ErrNotModified = errors.New("resource not modified")
+
+	// ErrStorageQuotaExceeded matches the errors of writes rejected because the logical partition is full.
+	// Unlike ErrTooManyRequests it isn't solved by more throughput: delete documents from the
+	// partition, or spread them with a partition key of higher cardinality.
+	ErrStorageQuotaExceeded = errors.New("storage quota exceeded")
+)
+
+// The status code of every sentinel error
+var statusErrors = map[error]int{
+	ErrPayloadTooLarge:    http.StatusRequestEntityTooLarge,
+	ErrNotFound:           http.StatusNotFound,
+	ErrConflict:           http.StatusConflict,
+	ErrPreconditionFailed: http.StatusPreconditionFailed,
+	ErrTooManyRequests:    http.StatusTooManyRequests,
+	ErrTimeout:            http.StatusRequestTimeout,
+	ErrNotModified:        http.StatusNotModified,
+}
+
 // Request Error
 type RequestError struct {
-	Code    string `json:"code"`
-	Message string `json:"message"`
+	Code       string `json:"code"`
+	Message    string `json:"message"`
+	StatusCode int    `json:"-"`
+	// SubStatus refines StatusCode, e.g: 1002 for a partition key range that is gone
+	SubStatus int `json:"-"`
+	// RetryAfter is the delay suggested by the server before retrying a throttled request
+	RetryAfter time.Duration `json:"-"`
+	// ActivityID is the id the server assigned to the failed request, give it to the support
+	ActivityID string `json:"-"`
+	// ResourceQuota and ResourceUsage are the storage quotas and usage of the collection,
+	// when the response reports them, e.g: with ErrStorageQuotaExceeded
+	ResourceQuota, ResourceUsage ResourceQuota `json:"-"`
+	// RequestID is the client request id of the failed call, see Config.RequestIDGenerator
+	RequestID string `json:"-"`
+	// DiagnosticContext is the application context of the failed call, see the DiagnosticContext option
+	DiagnosticContext map[string]string `json:"-"`
+	// The raw response body, for operations that report more than code and message
+	body []byte
+	// Set for documents reported as not found because they expired, see ReadUnexpiredDocument
+	expired bool
 }
 
 // Implement Error function
 func (e RequestError) Error() string {
-	return fmt.Sprintf("%v, %v", e.Code, e.Message)
+	var details []string
+	if e.RequestID != "" {
+		details = append(details, "request id: "+e.RequestID)
+	}
+	details = append(details, formatDiagnosticContext(e.DiagnosticContext)...)
+	if len(details) == 0 {
+		return fmt.Sprintf("%v, %v", e.Code, e.Message)
+	}
+	return fmt.Sprintf("%v, %v (%v)", e.Code, e.Message, strings.Join(details, ", "))
+}
+
+// Is reports whether the error matches one of the sentinel errors classifying
+// request failures, e.g: errors.Is(err, ErrNotFound)
+func (e RequestError) Is(target error) bool {
+	switch target {
+	case ErrExpired:
+		return e.expired
+	case ErrStorageQuotaExceeded:
+		return isStorageQuotaExceeded(e)
+	}
+	status, ok := statusErrors[target]
+	return ok && e.StatusCode == status
 }
 
 // Resource Request
 type Request struct {
 	rId, rType string
+	// idempotent overrides the retry classification of the operation, see Idempotent
+	idempotent *bool
+	// verifyCreate reads back the document of a failed Create before retrying it, see VerifyCreate
+	verifyCreate bool
+	// transform rewrites the documents of the response page, see TransformDocuments
+	transform DocumentTransform
+	// manualThrottling leaves the retries of throttled attempts to the caller, see throttledOptions
+	manualThrottling bool
+	// key signs the request instead of Config.MasterKey, see WithMasterKey
+	key *Key
+	// resourceToken authorizes the request instead of its key, see ResourceToken
+	resourceToken string
+	// span is the span of the call, see Config.Tracer
+	span Span
+	// stats is the outcome of the attempts of the call, see CallMetrics
+	stats callStats
+	// diagnosticContext is the application context of the call, see DiagnosticContext
+	diagnosticContext map[string]string
+	// decoder decodes the response instead of Serialization, see DecodeWith
+	decoder *SerializationDriver
 	*http.Request
 }
 
 // Return new resource request with type and id
 func ResourceRequest(link string, req *http.Request) *Request {
 	rId, rType := parse(link)
-	return &Request{rId, rType, req}
+	return &Request{rId: rId, rType: rType, Request: req}
 }
 
 // Add 3 default headers to *Request
 // "x-ms-date", "x-ms-version", "authorization"
 func (req *Request) DefaultHeaders(mKey *Key) (err error) {
-	req.Header.Add(HeaderXDate, formatDate(time.Now()))
-	req.Header.Add(HeaderVersion, SupportedVersion)
+	return req.defaultHeaders(mKey, time.Now())
+}
+
+// Set the default headers, signing the request for the given date.
+// It can be called again to sign the request for another date.
+// A version set by the options(e.g: BatchVersion) is kept.
+func (req *Request) defaultHeaders(mKey *Key, date time.Time) (err error) {
+	req.dateHeaders(date)
 
 	b := buffers.Get().(*bytes.Buffer)
 	b.Reset()
@@ -84,19 +218,44 @@ func (req *Request) DefaultHeaders(mKey *Key) (err error) {
 
 	buffers.Put(b)
 
-	req.Header.Add(HeaderAuth, url.QueryEscape("type=master&ver=1.0&sig="+sign))
+	req.Header.Set(HeaderAuth, url.QueryEscape("type=master&ver=1.0&sig="+sign))
 
 	return
 }
 
+// Set the date and version headers, keeping a version set by the options
+func (req *Request) dateHeaders(date time.Time) {
+	req.Header.Set(HeaderXDate, formatDate(date))
+	if req.Header.Get(HeaderVersion) == "" {
+		req.Header.Set(HeaderVersion, SupportedVersion)
+	}
+}
+
 // Add headers for query request
 func (req *Request) QueryHeaders(len int) {
-	req.Header.Add(HeaderContentType, "application/query+json")
-	req.Header.Add(HeaderIsQuery, "true")
-	req.Header.Add(HeaderContentLength, strconv.Itoa(len))
+	req.Header.Set(HeaderContentType, ContentTypeQueryJSON)
+	req.Header.Set(HeaderIsQuery, "true")
+	req.Header.Set(HeaderContentLength, strconv.Itoa(len))
+}
+
+// The driver decoding the response of the request, see DecodeWith
+func (req *Request) serialization() *SerializationDriver {
+	if req.decoder != nil {
+		return req.decoder
+	}
+	return &Serialization
 }
 
 func parse(id string) (rId, rType string) {
+	// The database account is signed with an empty resource id and type
+	if strings.Trim(id, "/") == "" {
+		return "", ""
+	}
+	// The partition key delete operation is signed for the collection it applies to
+	if i := strings.Index(id, "/"+partitionKeyDeletePath); i != -1 {
+		rId, _ = parse(id[:i+1])
+		return rId, "partitionkey"
+	}
 	if strings.HasPrefix(id, "/") == false {
 		id = "/" + id
 	}
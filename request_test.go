@@ -3,7 +3,10 @@ package documentdb
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -76,3 +79,113 @@ func TestPartitionKeyAsString(t *testing.T) {
 	assert := assert.New(t)
 	assert.Equal([]string{"[\"1\"]"}, req.Header[HeaderPartitionKey])
 }
+
+func TestPartitionKeyValues(t *testing.T) {
+	for value, header := range map[interface{}]string{
+		"1":              `["1"]`,
+		2.5:              `[2.5]`,
+		true:             `[true]`,
+		nil:              `[null]`,
+		PartitionKeyNone: `[{}]`,
+	} {
+		r, _ := http.NewRequest("GET", "link", &bytes.Buffer{})
+		req := ResourceRequest("/dbs/b5NCAA==/", r)
+		assert.NoError(t, PartitionKey(value)(req))
+		assert.Equal(t, []string{header}, req.Header[HeaderPartitionKey])
+	}
+}
+
+func TestContinuationExpected(t *testing.T) {
+	r, _ := http.NewRequest("POST", "link", &bytes.Buffer{})
+	req := ResourceRequest("/dbs/b5NCAA==/", r)
+
+	ContinuationExpected(true)(req)
+	assert.Empty(t, req.Header.Get(HeaderIsContinuationExpected), "should be ignored by non-query requests")
+
+	req.QueryHeaders(0)
+	ContinuationExpected(false)(req)
+	assert.Equal(t, "false", req.Header.Get(HeaderIsContinuationExpected))
+
+	ContinuationExpected(true)(req)
+	assert.Equal(t, "true", req.Header.Get(HeaderIsContinuationExpected))
+}
+
+func TestPriority(t *testing.T) {
+	r, _ := http.NewRequest("GET", "link", &bytes.Buffer{})
+	req := ResourceRequest("/dbs/b5NCAA==/", r)
+
+	for _, opt := range lowPriority([]CallOption{Priority(PriorityHigh)}) {
+		opt(req)
+	}
+	assert.Equal(t, "High", req.Header.Get(HeaderPriorityLevel), "should be overridden by the caller options")
+
+	lowPriority(nil)[0](req)
+	assert.Equal(t, "Low", req.Header.Get(HeaderPriorityLevel))
+}
+
+func TestMaxItemCount(t *testing.T) {
+	r, _ := http.NewRequest("POST", "link", &bytes.Buffer{})
+	req := ResourceRequest("/dbs/b5NCAA==/colls/Ad352/docs/", r)
+
+	assert.NoError(t, MaxItemCount(1000)(req))
+	assert.Equal(t, "1000", req.Header.Get(HeaderMaxItemCount))
+	assert.NoError(t, MaxItemCount(-1)(req))
+	assert.Equal(t, "-1", req.Header.Get(HeaderMaxItemCount))
+
+	assert.EqualError(t, MaxItemCount(0)(req), "max item count 0 is invalid, must be positive or -1")
+	assert.Error(t, MaxItemCount(-2)(req))
+}
+
+func TestThroughputBucket(t *testing.T) {
+	r, _ := http.NewRequest("GET", "link", &bytes.Buffer{})
+	req := ResourceRequest("/dbs/b5NCAA==/", r)
+
+	assert.NoError(t, ThroughputBucket(2)(req))
+	assert.Equal(t, "2", req.Header.Get(HeaderThroughputBucket))
+
+	assert.Error(t, ThroughputBucket(0)(req))
+	assert.Error(t, ThroughputBucket(6)(req))
+}
+
+func TestRequestErrorPayloadTooLarge(t *testing.T) {
+	var err error = &RequestError{StatusCode: http.StatusRequestEntityTooLarge}
+	assert.True(t, errors.Is(err, ErrPayloadTooLarge))
+	assert.True(t, errors.Is(&BatchError{Index: -1, RequestError: err.(*RequestError)}, ErrPayloadTooLarge))
+	assert.False(t, errors.Is(&RequestError{StatusCode: http.StatusBadRequest}, ErrPayloadTooLarge))
+}
+
+func TestRequestErrorStatus(t *testing.T) {
+	for status, target := range map[int]error{
+		http.StatusNotFound:           ErrNotFound,
+		http.StatusConflict:           ErrConflict,
+		http.StatusPreconditionFailed: ErrPreconditionFailed,
+		http.StatusTooManyRequests:    ErrTooManyRequests,
+		http.StatusRequestTimeout:     ErrTimeout,
+		http.StatusNotModified:        ErrNotModified,
+	} {
+		var err error = &RequestError{StatusCode: status}
+		assert.True(t, errors.Is(err, target), "status %d", status)
+		assert.True(t, errors.Is(fmt.Errorf("wrapped: %w", err), target), "status %d", status)
+		assert.False(t, errors.Is(&RequestError{StatusCode: http.StatusBadRequest}, target))
+	}
+	expired := &RequestError{StatusCode: http.StatusNotFound, expired: true}
+	assert.True(t, errors.Is(expired, ErrNotFound))
+	assert.True(t, errors.Is(expired, ErrExpired))
+	assert.False(t, errors.Is(&RequestError{StatusCode: http.StatusNotFound}, ErrExpired))
+}
+
+func TestRequestErrorActivityID(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(HeaderActivityID, "activity")
+		http.Error(w, `{"code": "Conflict", "message": "Entity with the specified id already exists"}`, http.StatusConflict)
+	}))
+	defer s.Close()
+	client := &Client{Url: s.URL, Config: NewConfig(&Key{Key: "YXJpZWwNCg=="})}
+
+	_, err := client.Create("dbs/db/colls/coll/docs/", `{"id": "1"}`, nil)
+	assert.True(t, errors.Is(err, ErrConflict))
+	var reqErr *RequestError
+	assert.True(t, errors.As(err, &reqErr))
+	assert.Equal(t, http.StatusConflict, reqErr.StatusCode)
+	assert.Equal(t, "activity", reqErr.ActivityID)
+}
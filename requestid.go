@@ -0,0 +1,33 @@
+package documentdb
+
+// RequestIDGenerator returns the client request id of a call, sent in the x-ms-client-request-id
+// header. Unlike the activity id assigned by the server, it's known before the call is sent, so it
+// correlates a call in your logs even when no response is received. Implementations must be safe for concurrent use.
+type RequestIDGenerator func() string
+
+// DefaultRequestIDGenerator returns a random UUID, it's the generator set by NewConfig
+func DefaultRequestIDGenerator() string {
+	return uuid()
+}
+
+// RequestID sets the client request id of the call instead of Config.RequestIDGenerator,
+// e.g: to reuse the id of the incoming request it serves
+func RequestID(id string) CallOption {
+	return func(r *Request) error {
+		r.Header.Set(HeaderClientRequestID, id)
+		return nil
+	}
+}
+
+// Return a new client request id, see Config.RequestIDGenerator
+func (c *Client) requestID() string {
+	if c.Config.RequestIDGenerator == nil {
+		return DefaultRequestIDGenerator()
+	}
+	return c.Config.RequestIDGenerator()
+}
+
+// The client request id of the call, shared by all its attempts
+func (req *Request) requestID() string {
+	return req.Header.Get(HeaderClientRequestID)
+}
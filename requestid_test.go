@@ -0,0 +1,57 @@
+package documentdb
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestID(t *testing.T) {
+	assert := assert.New(t)
+	s, client, _ := RetryServerFactory(http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusServiceUnavailable)
+	defer s.Close()
+	observer := &ObserverRecorder{}
+	client.Config.MetricsObserver = observer
+	ids := 0
+	client.Config.RequestIDGenerator = func() string {
+		ids++
+		return fmt.Sprintf("req-%d", ids)
+	}
+
+	_, err := client.Read("dbs/db/colls/coll/docs/1", nil)
+	var reqErr *RequestError
+	assert.True(errors.As(err, &reqErr))
+	assert.Equal("req-1", reqErr.RequestID)
+	assert.Equal("ServiceUnavailable, try again (request id: req-1)", err.Error())
+	// The retries share the id of the call
+	assert.Len(observer.Requests, 4)
+	for _, m := range observer.Requests {
+		assert.Equal("req-1", m.RequestID)
+	}
+
+	// The id can be set by the caller
+	_, err = client.Read("dbs/db/colls/coll/docs/1", nil, RequestID("incoming"))
+	assert.NoError(err)
+	assert.Equal("incoming", observer.Requests[len(observer.Requests)-1].RequestID)
+}
+
+func TestRequestIDHeader(t *testing.T) {
+	s := ServerFactory(`{}`, `{}`)
+	defer s.Close()
+	client := &Client{Url: s.URL, Config: NewConfig(&Key{Key: "YXJpZWwNCg=="})}
+
+	_, err := client.Read("dbs/db/", nil)
+	assert.NoError(t, err)
+	first := s.Header.Get(HeaderClientRequestID)
+	assert.Len(t, first, 36, "should be a UUID")
+
+	// A nil generator falls back to the default one
+	client.Config.RequestIDGenerator = nil
+	_, err = client.Read("dbs/db/", nil)
+	assert.NoError(t, err)
+	assert.Len(t, s.Header.Get(HeaderClientRequestID), 36)
+	assert.NotEqual(t, first, s.Header.Get(HeaderClientRequestID))
+}
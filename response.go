@@ -3,10 +3,22 @@ package documentdb
 import (
 	"math"
 	"net/http"
+	"strconv"
 )
 
 type Response struct {
 	Header http.Header
+	// Feed holds the envelope of a documents feed page, see QueryDocuments.
+	// It's nil for any other response.
+	Feed *FeedMetadata
+}
+
+// FeedMetadata holds the fields of a feed response envelope
+type FeedMetadata struct {
+	// Rid is the resource id of the collection the feed was read from
+	Rid string
+	// Count is the number of items in the page, as reported by the server
+	Count int
 }
 
 // Continuation returns continuation token for paged request.
@@ -15,6 +27,58 @@ func (r *Response) Continuation() string {
 	return r.Header.Get(HeaderContinuation)
 }
 
+// RequestCharge returns the request units consumed by the operation, zero if the response doesn't report it
+func (r *Response) RequestCharge() float64 {
+	if r == nil {
+		return 0
+	}
+	charge, _ := strconv.ParseFloat(r.Header.Get(HeaderRequestCharge), 64)
+	return charge
+}
+
+// IndexTransformationProgress returns the progress(0-100) of the collection index rebuild
+// following an indexing policy change. It returns -1 if the response doesn't report it.
+func (r *Response) IndexTransformationProgress() int {
+	v := r.Header.Get(HeaderIndexTransformation)
+	if v == "" {
+		return -1
+	}
+	progress, err := strconv.Atoi(v)
+	if err != nil {
+		return -1
+	}
+	return progress
+}
+
+// Diagnostics holds low level replication details of a response, useful
+// to reason about consistency and replication lag
+type Diagnostics struct {
+	// LSN is the logical sequence number of the replica that served the request
+	LSN int64
+	// GlobalCommittedLSN is the LSN committed across all regions
+	GlobalCommittedLSN int64
+	// QuorumAckedLSN is the LSN acknowledged by a quorum of replicas in the region
+	QuorumAckedLSN int64
+}
+
+// Diagnostics returns the replication details of the response.
+// Headers missing from the response are left zero.
+func (r *Response) Diagnostics() (d Diagnostics) {
+	d.LSN = r.parseInt(HeaderLSN)
+	d.GlobalCommittedLSN = r.parseInt(HeaderGlobalCommittedLSN)
+	d.QuorumAckedLSN = r.parseInt(HeaderQuorumAckedLSN)
+	return
+}
+
+func (r *Response) parseInt(header string) int64 {
+	v := r.Header.Get(header)
+	if v == "" {
+		return 0
+	}
+	n, _ := strconv.ParseInt(v, 10, 64)
+	return n
+}
+
 type statusCodeValidatorFunc func(statusCode int) bool
 
 func expectStatusCode(expected int) statusCodeValidatorFunc {
@@ -1,6 +1,7 @@
 package documentdb
 
 import (
+	"net/http"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -44,3 +45,28 @@ func TestExpectStatusCodeXX(t *testing.T) {
 	}
 
 }
+
+func TestResponseDiagnostics(t *testing.T) {
+	r := &Response{Header: http.Header{}}
+	assert.Equal(t, Diagnostics{}, r.Diagnostics(), "missing headers should be left zero")
+
+	r.Header.Set(HeaderLSN, "42")
+	r.Header.Set(HeaderGlobalCommittedLSN, "40")
+	r.Header.Set(HeaderQuorumAckedLSN, "41")
+	assert.Equal(t, Diagnostics{LSN: 42, GlobalCommittedLSN: 40, QuorumAckedLSN: 41}, r.Diagnostics())
+}
+
+func TestResponseIndexTransformationProgress(t *testing.T) {
+	r := &Response{Header: http.Header{}}
+	assert.Equal(t, -1, r.IndexTransformationProgress())
+	r.Header.Set(HeaderIndexTransformation, "42")
+	assert.Equal(t, 42, r.IndexTransformationProgress())
+}
+
+func TestResponseRequestCharge(t *testing.T) {
+	r := &Response{Header: http.Header{}}
+	assert.Equal(t, 0.0, r.RequestCharge())
+	r.Header.Set(HeaderRequestCharge, "2.86")
+	assert.Equal(t, 2.86, r.RequestCharge())
+	assert.Equal(t, 0.0, (*Response)(nil).RequestCharge())
+}
@@ -0,0 +1,226 @@
+package documentdb
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Returned when a Create can't be verified, see VerifyCreate
+var errNoDocumentId = errors.New("document has no id to verify the create with")
+
+// StatusRetryWith is returned when a write conflicts with a concurrent operation, the write isn't applied
+const StatusRetryWith = 449
+
+// Jitter randomizes the backoff delays, so the clients that failed together don't retry in lockstep
+type Jitter int
+
+const (
+	// JitterFull waits a random delay between zero and the backoff, the default
+	JitterFull Jitter = iota
+
+	// JitterNone waits exactly the backoff
+	JitterNone
+)
+
+// DefaultRetryOptions holds the retry options set by NewConfig. Failed requests are retried right
+// away, set InitialBackoff to space the retries out.
+var DefaultRetryOptions = RetryOptions{
+	MaxRetries:       3,
+	MaxRetryWaitTime: 30 * time.Second,
+}
+
+// RetryOptions configures the retries of transient failures done by the client.
+//
+// Operations are classified by whether repeating them is harmless:
+//   - Read, Query, Replace, Upsert and Delete are idempotent. They are retried on network errors,
+//     408(Request Timeout), 449(Retry With) and 503(Service Unavailable).
+//   - Create, Patch and Execute(stored procedures, batches) are not. A timed out or interrupted request
+//     may have been applied, so they are only retried on 449, which guarantees it wasn't.
+//     Use the VerifyCreate option to retry a document Create after checking it wasn't applied.
+//   - Throttled requests(429 Too Many Requests) are never applied, they're retried whatever the operation
+//     after the delay suggested by the server(x-ms-retry-after-ms), as long as MaxRetryWaitTime allows.
+//   - Requests failing with 410(Gone) while a partition is split or migrated are never applied either,
+//     they're retried whatever the operation once the cached partitioning metadata of the collection is
+//     dropped. Requests targeting a partition key range(e.g: ChangeFeedPartitionRangeID) aren't: the caller
+//     must move on to the child ranges, see IsPartitionSplit.
+//
+// Use the Idempotent option to override the classification of a single call.
+type RetryOptions struct {
+	// MaxRetries is the number of times a failed request is retried, not counting the initial attempt:
+	// a request is sent at most 1+MaxRetries times. Zero disables retries. Throttling retries count
+	// against it, the single resend of a request rejected for clock skew doesn't.
+	MaxRetries int
+	// InitialBackoff is the delay before the first retry, it's multiplied by BackoffMultiplier on every retry.
+	// The server suggested delay is used instead when it's longer. Zero retries right away.
+	InitialBackoff time.Duration
+	// BackoffMultiplier is the growth factor of the backoff, zero doubles it on every retry
+	BackoffMultiplier float64
+	// MaxBackoff caps the backoff, zero leaves it uncapped
+	MaxBackoff time.Duration
+	// Jitter randomizes the backoff, it's full jitter by default
+	Jitter Jitter
+	// MaxRetryWaitTime caps the total time a request waits for the retries of its throttled attempts,
+	// so a huge delay suggested by the server can't hang the caller. Zero disables throttling retries.
+	MaxRetryWaitTime time.Duration
+	// ServiceUnavailable and RequestTimeout tune the retries of 503(Service Unavailable) and
+	// 408(Request Timeout) failures apart from the other ones, nil uses MaxRetries and InitialBackoff
+	ServiceUnavailable, RequestTimeout *StatusRetryOptions
+}
+
+// StatusRetryOptions tunes the retries of the failures of a single status code, see RetryOptions
+type StatusRetryOptions struct {
+	// MaxRetries is the number of retries a request failing with the status may reach,
+	// zero fails it right away
+	MaxRetries int
+	// InitialBackoff is the delay before the first retry, zero uses RetryOptions.InitialBackoff
+	InitialBackoff time.Duration
+}
+
+// Return the options applying to the retries of the given failure
+func (o RetryOptions) forError(err error) RetryOptions {
+	reqErr, ok := err.(*RequestError)
+	if !ok {
+		return o
+	}
+	status := o.ServiceUnavailable
+	if reqErr.StatusCode == http.StatusRequestTimeout {
+		status = o.RequestTimeout
+	} else if reqErr.StatusCode != http.StatusServiceUnavailable {
+		return o
+	}
+	if status != nil {
+		o.MaxRetries = status.MaxRetries
+		if status.InitialBackoff > 0 {
+			o.InitialBackoff = status.InitialBackoff
+		}
+	}
+	return o
+}
+
+// The delay before the given retry(starting from zero):
+// min(MaxBackoff, InitialBackoff * BackoffMultiplier^retry), with jitter
+func (o RetryOptions) delay(retry int, err error) time.Duration {
+	multiplier := o.BackoffMultiplier
+	if multiplier <= 0 {
+		multiplier = 2
+	}
+	backoff := float64(o.InitialBackoff) * math.Pow(multiplier, float64(retry))
+	if o.MaxBackoff > 0 && backoff > float64(o.MaxBackoff) {
+		backoff = float64(o.MaxBackoff)
+	}
+	delay := time.Duration(math.MaxInt64)
+	if backoff < float64(math.MaxInt64) {
+		delay = time.Duration(backoff)
+	}
+	if o.Jitter == JitterFull && delay > 0 {
+		delay = time.Duration(rand.Int63n(int64(delay)))
+	}
+	if reqErr, ok := err.(*RequestError); ok && reqErr.RetryAfter > 0 {
+		// Throttled requests wait exactly as long as the server asks
+		if reqErr.StatusCode == http.StatusTooManyRequests || reqErr.RetryAfter > delay {
+			delay = reqErr.RetryAfter
+		}
+	}
+	return delay
+}
+
+// Check whether repeating the request is harmless, see RetryOptions
+func (req *Request) isIdempotent() bool {
+	if req.idempotent != nil {
+		return *req.idempotent
+	}
+	switch req.operation() {
+	case "Create", "Execute", "Patch":
+		return false
+	}
+	return true
+}
+
+// Check whether the request can be retried after the given failure
+func (req *Request) shouldRetry(err error) bool {
+	if req.Context().Err() != nil {
+		return false
+	}
+	reqErr, ok := err.(*RequestError)
+	if !ok {
+		// Network error, the request may or may not have reached the server
+		return req.isIdempotent()
+	}
+	switch reqErr.StatusCode {
+	case StatusRetryWith:
+		return true
+	case http.StatusTooManyRequests:
+		return !req.manualThrottling
+	case http.StatusGone:
+		return req.Header.Get(HeaderPartitionKeyRangeID) == ""
+	case http.StatusRequestTimeout, http.StatusServiceUnavailable:
+		return req.isIdempotent()
+	}
+	return false
+}
+
+// Check whether the request must be verified before it's retried after the given failure, see VerifyCreate
+func (req *Request) shouldVerify(err error) bool {
+	if !req.verifyCreate || req.isIdempotent() || req.operation() != "Create" || req.Context().Err() != nil {
+		return false
+	}
+	reqErr, ok := err.(*RequestError)
+	return !ok || reqErr.StatusCode == http.StatusRequestTimeout || reqErr.StatusCode == http.StatusServiceUnavailable
+}
+
+// Read back the document of a failed Create by its id, the response is nil if it wasn't created
+func (c *Client) verifyCreated(r *Request, data interface{}) (*Response, error) {
+	if r.GetBody == nil {
+		return nil, errNoDocumentId
+	}
+	body, err := r.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	var doc struct {
+		Id string `json:"id"`
+	}
+	if err = readJson(body, &doc); err != nil || doc.Id == "" {
+		return nil, errNoDocumentId
+	}
+	base, err := url.Parse(c.Url)
+	if err != nil {
+		return nil, err
+	}
+	link := strings.TrimPrefix(r.URL.Path, strings.TrimSuffix(base.Path, "/")+"/") + doc.Id
+	resp, err := c.Read(link, data, WithContext(r.Context()), WithMasterKey(r.key), ResourceToken(r.resourceToken), func(req *Request) error {
+		if pk, ok := r.Header[HeaderPartitionKey]; ok {
+			req.Header[HeaderPartitionKey] = pk
+		}
+		return nil
+	})
+	if reqErr, ok := err.(*RequestError); ok && reqErr.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	return resp, err
+}
+
+// Prepare the request to be sent again, once signed again: rewind its body
+func (req *Request) rewind() (err error) {
+	if req.GetBody != nil {
+		req.Body, err = req.GetBody()
+	}
+	return err
+}
+
+// Drop the cached partitioning metadata of the collection of the request, after its topology changed
+func (c *Client) invalidateMetadata(r *Request) {
+	cache := c.Config.MetadataCache
+	coll := sessionCollection(r.URL.Path)
+	if cache == nil || coll == "" {
+		return
+	}
+	// The collection links may be cached with or without their trailing slash
+	cache.Invalidate(coll)
+	cache.Invalidate(coll + "/")
+}
@@ -0,0 +1,384 @@
+package documentdb
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Fail the first requests with the given statuses, then echo the request body
+func RetryServerFactory(statuses ...int) (*httptest.Server, *Client, *int) {
+	calls := new(int)
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		if *calls++; *calls <= len(statuses) {
+			http.Error(w, `{"code": "ServiceUnavailable", "message": "try again"}`, statuses[*calls-1])
+			return
+		}
+		if r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/docs/") {
+			w.WriteHeader(http.StatusCreated)
+		}
+		w.Write(b)
+	}))
+	config := NewConfig(&Key{Key: "YXJpZWwNCg=="})
+	config.RetryOptions = RetryOptions{MaxRetries: 3, InitialBackoff: time.Millisecond, Jitter: JitterNone}
+	return s, &Client{Url: s.URL, Config: config}, calls
+}
+
+func TestRetryIdempotent(t *testing.T) {
+	s, client, calls := RetryServerFactory(http.StatusServiceUnavailable, http.StatusRequestTimeout)
+	defer s.Close()
+
+	var doc Document
+	_, err := client.Replace("dbs/db/colls/coll/docs/1", `{"id": "1"}`, &doc)
+	assert.NoError(t, err)
+	assert.Equal(t, "1", doc.Id, "should send the body again on retry")
+	assert.Equal(t, 3, *calls)
+}
+
+func TestRetryMaxRetries(t *testing.T) {
+	s, client, calls := RetryServerFactory(503, 503, 503, 503, 503)
+	defer s.Close()
+
+	_, err := client.Read("dbs/db/colls/coll/docs/1", &Document{})
+	assert.Equal(t, http.StatusServiceUnavailable, err.(*RequestError).StatusCode)
+	assert.Equal(t, 4, *calls)
+}
+
+func TestRetryMaxRetriesBoundaries(t *testing.T) {
+	for maxRetries := 0; maxRetries <= 3; maxRetries++ {
+		// Failing exactly MaxRetries times succeeds on the last allowed attempt
+		statuses := make([]int, maxRetries)
+		for i := range statuses {
+			statuses[i] = http.StatusServiceUnavailable
+		}
+		s, client, calls := RetryServerFactory(statuses...)
+		client.Config.RetryOptions.MaxRetries = maxRetries
+		_, err := client.Read("dbs/db/colls/coll/docs/1", nil)
+		assert.NoError(t, err, "max retries %d", maxRetries)
+		assert.Equal(t, 1+maxRetries, *calls, "max retries %d", maxRetries)
+		s.Close()
+
+		// Failing once more fails the request, without an extra attempt
+		s, client, calls = RetryServerFactory(append(statuses, http.StatusServiceUnavailable)...)
+		client.Config.RetryOptions.MaxRetries = maxRetries
+		_, err = client.Read("dbs/db/colls/coll/docs/1", nil)
+		assert.Error(t, err, "max retries %d", maxRetries)
+		assert.Equal(t, 1+maxRetries, *calls, "max retries %d", maxRetries)
+		s.Close()
+	}
+}
+
+func TestRetryDisabled(t *testing.T) {
+	s, client, calls := RetryServerFactory(http.StatusServiceUnavailable)
+	defer s.Close()
+	client.Config.RetryOptions = RetryOptions{}
+
+	_, err := client.Read("dbs/db/colls/coll/docs/1", &Document{})
+	assert.Error(t, err)
+	assert.Equal(t, 1, *calls)
+}
+
+func TestRetryNonIdempotent(t *testing.T) {
+	s, client, calls := RetryServerFactory(http.StatusServiceUnavailable)
+	defer s.Close()
+
+	_, err := client.Create("dbs/db/colls/coll/docs/", `{"id": "1"}`, &Document{})
+	assert.Equal(t, http.StatusServiceUnavailable, err.(*RequestError).StatusCode)
+	assert.Equal(t, 1, *calls, "should not retry a create that may have been applied")
+
+	s, client, calls = RetryServerFactory(StatusRetryWith)
+	defer s.Close()
+	_, err = client.Create("dbs/db/colls/coll/docs/", `{"id": "1"}`, &Document{})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, *calls, "should retry a create that wasn't applied")
+}
+
+func TestRetryPatch(t *testing.T) {
+	s, client, calls := RetryServerFactory(http.StatusServiceUnavailable)
+	defer s.Close()
+	_, err := client.Patch("dbs/db/colls/coll/docs/1", []PatchOperation{{Op: PatchIncrement, Path: "/n", Value: 1}}, nil)
+	assert.Error(t, err)
+	assert.Equal(t, 1, *calls, "should not retry a patch that may have been applied")
+}
+
+func TestRetryPartitionGone(t *testing.T) {
+	s, client, calls := RetryServerFactory(http.StatusGone)
+	defer s.Close()
+	cache := client.Config.MetadataCache
+	cache.SetPartitionKeyRanges("dbs/db/colls/coll/", []PartitionKeyRange{{Resource: Resource{Id: "0"}}})
+	_, err := client.Create("dbs/db/colls/coll/docs/", `{"id": "1"}`, &Document{})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, *calls, "should retry a create rejected while the partition moved")
+	_, ok := cache.PartitionKeyRanges("dbs/db/colls/coll/")
+	assert.False(t, ok, "should drop the cached partition key ranges")
+
+	s, client, calls = RetryServerFactory(http.StatusGone)
+	defer s.Close()
+	_, err = client.Read("dbs/db/colls/coll/docs/", &Document{}, ChangeFeedPartitionRangeID("0"))
+	assert.Error(t, err)
+	assert.Equal(t, 1, *calls, "should leave the requests of a range that is gone to the caller")
+}
+
+func TestRetryIdempotentOverride(t *testing.T) {
+	s, client, calls := RetryServerFactory(http.StatusServiceUnavailable)
+	defer s.Close()
+	_, err := client.Execute("dbs/db/colls/coll/sprocs/fn", `[]`, nil, Idempotent(true))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, *calls)
+
+	s, client, calls = RetryServerFactory(http.StatusRequestTimeout)
+	defer s.Close()
+	_, err = client.Replace("dbs/db/colls/coll/docs/1", `{"id": "1"}`, nil, Idempotent(false))
+	assert.Error(t, err)
+	assert.Equal(t, 1, *calls)
+}
+
+func TestRetryDelay(t *testing.T) {
+	o := RetryOptions{InitialBackoff: 10 * time.Millisecond, Jitter: JitterNone}
+	assert.Equal(t, 10*time.Millisecond, o.delay(0, errors.New("network error")))
+	assert.Equal(t, 40*time.Millisecond, o.delay(2, &RequestError{}))
+	assert.Equal(t, time.Second, o.delay(0, &RequestError{RetryAfter: time.Second}))
+
+	o.BackoffMultiplier, o.MaxBackoff = 3, 50*time.Millisecond
+	assert.Equal(t, 30*time.Millisecond, o.delay(1, &RequestError{}))
+	assert.Equal(t, 50*time.Millisecond, o.delay(2, &RequestError{}), "should be capped")
+	assert.Equal(t, 50*time.Millisecond, o.delay(100, &RequestError{}), "should be capped")
+
+	o.Jitter = JitterFull
+	for retry := 0; retry < 10; retry++ {
+		delay := o.delay(retry, &RequestError{})
+		assert.True(t, delay >= 0 && delay < 50*time.Millisecond, "delay %v should be within the backoff", delay)
+	}
+	// The server suggested delay isn't jittered
+	assert.Equal(t, time.Second, o.delay(0, &RequestError{RetryAfter: time.Second}))
+}
+
+func TestRetryDelayDefaults(t *testing.T) {
+	o := NewConfig(&Key{Key: "YXJpZWwNCg=="}).RetryOptions
+	assert.Equal(t, JitterFull, o.Jitter)
+	for retry := 0; retry < 3; retry++ {
+		assert.Equal(t, time.Duration(0), o.delay(retry, &RequestError{}), "should retry right away")
+	}
+	assert.Equal(t, time.Second, o.delay(0, &RequestError{RetryAfter: time.Second}))
+}
+
+func TestRequestTimeout(t *testing.T) {
+	var calls int32
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			select {
+			case <-time.After(time.Second):
+			case <-r.Context().Done():
+			}
+			return
+		}
+		w.Write([]byte(`{"id": "1"}`))
+	}))
+	defer s.Close()
+	config := NewConfig(&Key{Key: "YXJpZWwNCg=="})
+	config.RequestTimeout = 50 * time.Millisecond
+	config.RetryOptions = RetryOptions{MaxRetries: 1, InitialBackoff: time.Millisecond}
+	client := &Client{Url: s.URL, Config: config}
+
+	start := time.Now()
+	var doc Document
+	_, err := client.Read("dbs/db/colls/coll/docs/1", &doc)
+	assert.NoError(t, err)
+	assert.Equal(t, "1", doc.Id)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls), "should abandon the slow attempt and retry")
+	assert.True(t, time.Since(start) < time.Second)
+}
+
+func TestRetryVerifyCreate(t *testing.T) {
+	for _, applied := range []bool{true, false} {
+		var (
+			stored []byte
+			posts  int
+			reads  int
+		)
+		s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodGet {
+				reads++
+				assert.Equal(t, "/dbs/db/colls/coll/docs/1", r.URL.Path)
+				assert.Equal(t, `["a"]`, r.Header.Get(HeaderPartitionKey))
+				if stored == nil {
+					http.Error(w, `{"code": "NotFound", "message": "Resource Not Found"}`, http.StatusNotFound)
+					return
+				}
+				w.Write(stored)
+				return
+			}
+			b, _ := ioutil.ReadAll(r.Body)
+			if posts++; posts == 1 {
+				if applied {
+					stored = b
+				}
+				http.Error(w, `{"code": "RequestTimeout", "message": "timed out"}`, http.StatusRequestTimeout)
+				return
+			}
+			stored = b
+			w.WriteHeader(http.StatusCreated)
+			w.Write(b)
+		}))
+		config := NewConfig(&Key{Key: "YXJpZWwNCg=="})
+		config.RetryOptions = RetryOptions{MaxRetries: 3, InitialBackoff: time.Millisecond}
+		client := &Client{Url: s.URL, Config: config}
+
+		var doc Document
+		_, err := client.Create("dbs/db/colls/coll/docs/", `{"id": "1"}`, &doc, PartitionKey("a"), VerifyCreate())
+		assert.NoError(t, err)
+		assert.Equal(t, "1", doc.Id)
+		assert.Equal(t, 1, reads)
+		if applied {
+			assert.Equal(t, 1, posts, "should return the document the failed attempt created")
+		} else {
+			assert.Equal(t, 2, posts, "should create the document the failed attempt didn't")
+		}
+		s.Close()
+	}
+}
+
+func TestRetryVerifyCreateWithoutId(t *testing.T) {
+	s, client, calls := RetryServerFactory(http.StatusServiceUnavailable)
+	defer s.Close()
+
+	_, err := client.Create("dbs/db/colls/coll/docs/", `{"name": "a"}`, &Document{}, VerifyCreate())
+	assert.Equal(t, http.StatusServiceUnavailable, err.(*RequestError).StatusCode)
+	assert.Equal(t, 1, *calls, "can't verify a document without id")
+}
+
+func TestRetryContextCancelled(t *testing.T) {
+	s, client, calls := RetryServerFactory(503, 503, 503)
+	defer s.Close()
+	client.Config.RetryOptions = RetryOptions{MaxRetries: 3, InitialBackoff: time.Second}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+	_, err := client.Read("dbs/db/colls/coll/docs/1", &Document{}, WithContext(ctx))
+	assert.Equal(t, context.Canceled, err, "should fail with the context error, not the 503")
+	assert.Equal(t, 1, *calls)
+
+	_, err = client.Read("dbs/db/colls/coll/docs/1", &Document{}, WithContext(ctx))
+	assert.True(t, errors.Is(err, context.Canceled))
+	assert.Equal(t, 1, *calls, "should not send a request with a cancelled context")
+}
+
+func TestRequestContextInFlight(t *testing.T) {
+	release := make(chan struct{})
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer s.Close()
+	defer close(release)
+	client := &Client{Url: s.URL, Config: NewConfig(&Key{Key: "YXJpZWwNCg=="})}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := client.Read("dbs/db/colls/coll/docs/1", &Document{}, WithContext(ctx))
+	assert.Equal(t, context.DeadlineExceeded, err)
+}
+
+func TestRetryThrottled(t *testing.T) {
+	var delays []time.Duration
+	last := time.Now()
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		delays = append(delays, time.Since(last))
+		last = time.Now()
+		if len(delays) < 3 {
+			w.Header().Set(HeaderRetryAfter, "20")
+			http.Error(w, `{"code": "429", "message": "Request rate is large"}`, http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id": "1"}`))
+	}))
+	defer s.Close()
+	config := NewConfig(&Key{Key: "YXJpZWwNCg=="})
+	config.RetryOptions = RetryOptions{MaxRetries: 3, InitialBackoff: time.Millisecond, MaxRetryWaitTime: time.Second}
+	client := &Client{Url: s.URL, Config: config}
+
+	_, err := client.Create("dbs/db/colls/coll/docs/", `{"id": "1"}`, &Document{})
+	assert.NoError(t, err, "should retry a throttled create, it wasn't applied")
+	if assert.Len(t, delays, 3) {
+		assert.True(t, delays[1] >= 20*time.Millisecond, "should wait the delay suggested by the server")
+		assert.True(t, delays[2] >= 20*time.Millisecond, "should wait the delay suggested by the server")
+	}
+}
+
+func TestRetryThrottledMaxRetryWaitTime(t *testing.T) {
+	s, client, calls := RetryServerFactory(http.StatusTooManyRequests, http.StatusTooManyRequests, http.StatusTooManyRequests)
+	defer s.Close()
+	client.Config.RetryOptions = RetryOptions{MaxRetries: 3, InitialBackoff: 10 * time.Millisecond, MaxRetryWaitTime: 25 * time.Millisecond, Jitter: JitterNone}
+
+	// Waits 10ms then 20ms, over the cap
+	_, err := client.Read("dbs/db/colls/coll/docs/1", nil)
+	assert.Equal(t, http.StatusTooManyRequests, err.(*RequestError).StatusCode)
+	assert.Equal(t, 2, *calls)
+
+	client.Config.RetryOptions.MaxRetryWaitTime = 0
+	_, err = client.Read("dbs/db/colls/coll/docs/1", nil)
+	assert.Error(t, err)
+	assert.Equal(t, 3, *calls, "should not retry throttled requests without MaxRetryWaitTime")
+}
+
+func TestRetryServiceUnavailable(t *testing.T) {
+	s, client, calls := RetryServerFactory(503, 503, 503, 503, 503)
+	defer s.Close()
+	client.Config.RetryOptions.ServiceUnavailable = &StatusRetryOptions{MaxRetries: 5}
+
+	_, err := client.Read("dbs/db/colls/coll/docs/1", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 6, *calls)
+
+	// Other failures keep the default retries
+	s, client, calls = RetryServerFactory(408, 408, 408, 408, 408)
+	defer s.Close()
+	client.Config.RetryOptions.ServiceUnavailable = &StatusRetryOptions{MaxRetries: 5}
+	_, err = client.Read("dbs/db/colls/coll/docs/1", nil)
+	assert.True(t, errors.Is(err, ErrTimeout))
+	assert.Equal(t, 4, *calls)
+}
+
+func TestRetryRequestTimeout(t *testing.T) {
+	s, client, calls := RetryServerFactory(408, 408)
+	defer s.Close()
+	// Fail right away on timeouts
+	client.Config.RetryOptions.RequestTimeout = &StatusRetryOptions{}
+
+	_, err := client.Read("dbs/db/colls/coll/docs/1", nil)
+	assert.True(t, errors.Is(err, ErrTimeout))
+	assert.Equal(t, 1, *calls)
+
+	s, client, calls = RetryServerFactory(503, 503)
+	defer s.Close()
+	client.Config.RetryOptions.RequestTimeout = &StatusRetryOptions{}
+	_, err = client.Read("dbs/db/colls/coll/docs/1", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, *calls)
+}
+
+func TestRetryStatusOptions(t *testing.T) {
+	o := RetryOptions{
+		MaxRetries:         3,
+		InitialBackoff:     time.Millisecond,
+		ServiceUnavailable: &StatusRetryOptions{MaxRetries: 5, InitialBackoff: time.Second},
+		RequestTimeout:     &StatusRetryOptions{MaxRetries: 1},
+	}
+	unavailable := o.forError(&RequestError{StatusCode: http.StatusServiceUnavailable})
+	assert.Equal(t, 5, unavailable.MaxRetries)
+	assert.Equal(t, time.Second, unavailable.InitialBackoff)
+	timeout := o.forError(&RequestError{StatusCode: http.StatusRequestTimeout})
+	assert.Equal(t, 1, timeout.MaxRetries)
+	assert.Equal(t, time.Millisecond, timeout.InitialBackoff)
+	assert.Equal(t, o, o.forError(&RequestError{StatusCode: StatusRetryWith}))
+	assert.Equal(t, o, o.forError(errors.New("network error")))
+}
@@ -0,0 +1,223 @@
+package documentdb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/bits"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// ErrUnsupportedHashVersion is returned when the effective partition key of a collection
+// using the legacy(version 1) hash function is requested, only version 2 is supported
+var ErrUnsupportedHashVersion = errors.New("effective partition key requires partition key version 2")
+
+// Binary markers of the partition key components, used for hashing
+const (
+	pkComponentNull   = 0x01
+	pkComponentFalse  = 0x02
+	pkComponentTrue   = 0x03
+	pkComponentNumber = 0x05
+	pkComponentString = 0x08
+)
+
+// RoutingMetadata holds the routing details the gateway reports with a response
+type RoutingMetadata struct {
+	// PartitionKeyRangeID is the id of the partition key range that served the request
+	PartitionKeyRangeID string
+	// CollectionRid is the resource id of the collection the request targeted
+	CollectionRid string
+	// CollectionPath is the name based link of the collection, e.g: "dbs/db/colls/coll"
+	CollectionPath string
+}
+
+// Routing returns the routing details of the response, headers missing from the response are left empty
+func (r *Response) Routing() RoutingMetadata {
+	return RoutingMetadata{
+		PartitionKeyRangeID: r.Header.Get(HeaderPartitionKeyRangeID),
+		CollectionRid:       r.Header.Get(HeaderContentPath),
+		CollectionPath:      r.Header.Get(HeaderAltContentPath),
+	}
+}
+
+// RoutingMap maps the partition key values of a collection to the partition key ranges storing them.
+//
+// This is an advanced, low level capability for clients routing requests themselves, e.g: to send
+// a query per partition key range with the ChangeFeedPartitionRangeID option. Regular clients
+// should let the gateway route requests by partition key.
+// The map is a snapshot: after a range is split or merged(410 with SubStatusPartitionKeyRangeGone),
+// invalidate the collection in the metadata cache and read the map again.
+type RoutingMap struct {
+	Definition PartitionKeyDefinition
+	// Ranges sorted by MinInclusive
+	Ranges []PartitionKeyRange
+}
+
+// ReadRoutingMap reads the routing map of a partitioned collection by self link.
+// The partition key definition and ranges are served from the metadata cache when possible.
+func (c *DocumentDB) ReadRoutingMap(coll string, opts ...CallOption) (*RoutingMap, error) {
+	def, err := c.ReadPartitionKeyDefinition(coll, opts...)
+	if err != nil {
+		return nil, err
+	}
+	ranges, err := c.ReadPartitionKeyRanges(coll, opts...)
+	if err != nil {
+		return nil, err
+	}
+	ranges = append([]PartitionKeyRange(nil), ranges...)
+	sort.Slice(ranges, func(i, j int) bool {
+		return ranges[i].MinInclusive < ranges[j].MinInclusive
+	})
+	return &RoutingMap{Definition: def, Ranges: ranges}, nil
+}
+
+// Resolve returns the partition key range storing the given partition key value.
+// Pass a slice with a value per path for hierarchical partition keys.
+func (m *RoutingMap) Resolve(partitionKey interface{}) (*PartitionKeyRange, error) {
+	epk, err := EffectivePartitionKey(m.Definition, partitionKey)
+	if err != nil {
+		return nil, err
+	}
+	return m.RangeOf(epk)
+}
+
+// RangeOf returns the partition key range that contains the effective partition key
+func (m *RoutingMap) RangeOf(epk string) (*PartitionKeyRange, error) {
+	i := sort.Search(len(m.Ranges), func(i int) bool {
+		return m.Ranges[i].MinInclusive > epk
+	}) - 1
+	if i < 0 || (m.Ranges[i].MaxInclusive != "" && epk >= m.Ranges[i].MaxInclusive) {
+		return nil, fmt.Errorf("no partition key range contains %q", epk)
+	}
+	return &m.Ranges[i], nil
+}
+
+// EffectivePartitionKey returns the hashed value the server routes the partition key value by,
+// comparable with the MinInclusive and MaxInclusive bounds of the partition key ranges.
+// Pass a slice with a value per path for hierarchical partition keys, every value is hashed on its own.
+// Only partition keys of version 2 are supported, see ErrUnsupportedHashVersion.
+func EffectivePartitionKey(def PartitionKeyDefinition, partitionKey interface{}) (string, error) {
+	if def.Version < 2 {
+		return "", ErrUnsupportedHashVersion
+	}
+	values, ok := partitionKey.([]interface{})
+	if !ok {
+		values = []interface{}{partitionKey}
+	}
+	if len(values) != len(def.Paths) {
+		return "", fmt.Errorf("partition key has %d values, expected %d", len(values), len(def.Paths))
+	}
+	var epk strings.Builder
+	for _, value := range values {
+		var buf bytes.Buffer
+		if err := writePartitionKeyComponent(&buf, value); err != nil {
+			return "", err
+		}
+		h1, h2 := murmur3x64(buf.Bytes())
+		hash := make([]byte, 16)
+		binary.BigEndian.PutUint64(hash, h2)
+		binary.BigEndian.PutUint64(hash[8:], h1)
+		// Reset the 2 most significant bits, the upper bound of the ranges is "FF"
+		hash[0] &= 0x3F
+		epk.WriteString(strings.ToUpper(hex.EncodeToString(hash)))
+	}
+	return epk.String(), nil
+}
+
+// Write the binary encoding of a partition key value used for hashing
+func writePartitionKeyComponent(buf *bytes.Buffer, value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		buf.WriteByte(pkComponentNull)
+		return nil
+	case bool:
+		if v {
+			buf.WriteByte(pkComponentTrue)
+		} else {
+			buf.WriteByte(pkComponentFalse)
+		}
+		return nil
+	case string:
+		buf.WriteByte(pkComponentString)
+		buf.WriteString(v)
+		buf.WriteByte(0xFF)
+		return nil
+	case json.Number:
+		f, err := v.Float64()
+		if err != nil {
+			return err
+		}
+		value = f
+	}
+	rv := reflect.ValueOf(value)
+	var f float64
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		f = float64(rv.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		f = float64(rv.Uint())
+	case reflect.Float32, reflect.Float64:
+		f = rv.Float()
+	default:
+		return fmt.Errorf("partition key value of type %T must be a string, number, boolean or null", value)
+	}
+	buf.WriteByte(pkComponentNumber)
+	return binary.Write(buf, binary.LittleEndian, math.Float64bits(f))
+}
+
+// MurmurHash3 x64 128-bit with a zero seed
+func murmur3x64(data []byte) (h1, h2 uint64) {
+	const (
+		c1 = 0x87c37b91114253d5
+		c2 = 0x4cf5ad432745937f
+	)
+	n := len(data)
+	for ; len(data) >= 16; data = data[16:] {
+		k1 := binary.LittleEndian.Uint64(data)
+		k2 := binary.LittleEndian.Uint64(data[8:])
+		h1 ^= bits.RotateLeft64(k1*c1, 31) * c2
+		h1 = (bits.RotateLeft64(h1, 27)+h2)*5 + 0x52dce729
+		h2 ^= bits.RotateLeft64(k2*c2, 33) * c1
+		h2 = (bits.RotateLeft64(h2, 31)+h1)*5 + 0x38495ab5
+	}
+	var k1, k2 uint64
+	for i := len(data) - 1; i >= 8; i-- {
+		k2 = k2<<8 | uint64(data[i])
+	}
+	if len(data) > 8 {
+		h2 ^= bits.RotateLeft64(k2*c2, 33) * c1
+	}
+	tail := len(data)
+	if tail > 8 {
+		tail = 8
+	}
+	for i := tail - 1; i >= 0; i-- {
+		k1 = k1<<8 | uint64(data[i])
+	}
+	if len(data) > 0 {
+		h1 ^= bits.RotateLeft64(k1*c1, 31) * c2
+	}
+	h1 ^= uint64(n)
+	h2 ^= uint64(n)
+	h1 += h2
+	h2 += h1
+	h1, h2 = fmix64(h1), fmix64(h2)
+	h1 += h2
+	h2 += h1
+	return h1, h2
+}
+
+func fmix64(k uint64) uint64 {
+	k ^= k >> 33
+	k *= 0xff51afd7ed558ccd
+	k ^= k >> 33
+	k *= 0xc4ceb9e63e5a2a29
+	k ^= k >> 33
+	return k
+}
@@ -0,0 +1,81 @@
+package documentdb
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResponseRouting(t *testing.T) {
+	header := http.Header{}
+	header.Set(HeaderPartitionKeyRangeID, "3")
+	header.Set(HeaderContentPath, "b5NCAIu9NwA=")
+	header.Set(HeaderAltContentPath, "dbs/db/colls/coll")
+	resp := &Response{Header: header}
+	assert.Equal(t, RoutingMetadata{PartitionKeyRangeID: "3", CollectionRid: "b5NCAIu9NwA=", CollectionPath: "dbs/db/colls/coll"}, resp.Routing())
+}
+
+func TestEffectivePartitionKey(t *testing.T) {
+	assert := assert.New(t)
+	def := PartitionKeyDefinition{Paths: []string{"/tenant"}, Kind: "Hash", Version: 2}
+	epk, err := EffectivePartitionKey(def, "tenant")
+	assert.NoError(err)
+	assert.Regexp(regexp.MustCompile(`^[0-3][0-9A-F]{31}$`), epk)
+
+	// Numbers hash the same whatever their go type, but not as strings
+	one, _ := EffectivePartitionKey(def, 1)
+	for _, v := range []interface{}{int64(1), 1.0, uint8(1), json.Number("1")} {
+		other, err := EffectivePartitionKey(def, v)
+		assert.NoError(err)
+		assert.Equal(one, other)
+	}
+	other, err := EffectivePartitionKey(def, "1")
+	assert.NoError(err)
+	assert.NotEqual(one, other)
+
+	_, err = EffectivePartitionKey(def, map[string]interface{}{})
+	assert.Error(err)
+	_, err = EffectivePartitionKey(PartitionKeyDefinition{Paths: []string{"/tenant"}}, "tenant")
+	assert.Equal(ErrUnsupportedHashVersion, err)
+
+	multi := PartitionKeyDefinition{Paths: []string{"/tenant", "/user"}, Kind: "MultiHash", Version: 2}
+	hierarchical, err := EffectivePartitionKey(multi, []interface{}{"tenant", "user"})
+	assert.NoError(err)
+	assert.Len(hierarchical, 64)
+	assert.Equal(epk, hierarchical[:32])
+	_, err = EffectivePartitionKey(multi, "tenant")
+	assert.EqualError(err, "partition key has 1 values, expected 2")
+}
+
+func TestReadRoutingMap(t *testing.T) {
+	assert := assert.New(t)
+	config := NewConfig(&Key{Key: "YXJpZWwNCg=="})
+	config.MetadataCache = NewMemoryMetadataCache(DefaultMetadataCacheTTL)
+	config.MetadataCache.SetPartitionKeyDefinition("coll/", PartitionKeyDefinition{Paths: []string{"/tenant"}, Version: 2})
+	config.MetadataCache.SetPartitionKeyRanges("coll/", []PartitionKeyRange{
+		{PartitionKeyRangeID: "2", MinInclusive: "2000", MaxInclusive: "FF"},
+		{PartitionKeyRangeID: "0", MinInclusive: "", MaxInclusive: "1000"},
+		{PartitionKeyRangeID: "1", MinInclusive: "1000", MaxInclusive: "2000"},
+	})
+	c := New("https://localhost", config)
+
+	m, err := c.ReadRoutingMap("coll/")
+	assert.NoError(err)
+	for epk, id := range map[string]string{"": "0", "0FFF": "0", "1000": "1", "1FFF": "1", "2000": "2", "3FFF": "2"} {
+		r, err := m.RangeOf(epk)
+		if assert.NoError(err, epk) {
+			assert.Equal(id, r.PartitionKeyRangeID, epk)
+		}
+	}
+	_, err = m.RangeOf("FF")
+	assert.Error(err)
+
+	epk, _ := EffectivePartitionKey(m.Definition, "tenant")
+	expected, _ := m.RangeOf(epk)
+	r, err := m.Resolve("tenant")
+	assert.NoError(err)
+	assert.Equal(expected, r)
+}
@@ -0,0 +1,112 @@
+package documentdb
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// SessionToken returns the session token of the response, pass it to the SessionToken option of
+// the following reads to see the writes of this session under Session consistency
+func (r *Response) SessionToken() string {
+	return r.Header.Get(HeaderSessionToken)
+}
+
+// The latest session tokens of every collection, see Config.AutoTrackSessionToken
+type sessionTokens struct {
+	mu sync.RWMutex
+	// tokens maps a collection to the token of each of its partition key ranges
+	tokens map[string]map[string]string
+}
+
+// Merge the session token of a response in the tokens of the collection, keeping the
+// most recent token of every partition key range
+func (s *sessionTokens) update(coll, token string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.tokens == nil {
+		s.tokens = make(map[string]map[string]string)
+	}
+	ranges, ok := s.tokens[coll]
+	if !ok {
+		ranges = make(map[string]string)
+		s.tokens[coll] = ranges
+	}
+	for _, segment := range strings.Split(token, ",") {
+		id, lsn, ok := parseSessionSegment(segment)
+		if !ok {
+			continue
+		}
+		if current, ok := ranges[id]; ok {
+			if _, currentLSN, _ := parseSessionSegment(current); currentLSN > lsn {
+				continue
+			}
+		}
+		ranges[id] = strings.TrimSpace(segment)
+	}
+}
+
+// The session token of the collection, empty if none was tracked
+func (s *sessionTokens) get(coll string) string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	segments := make([]string, 0, len(s.tokens[coll]))
+	for _, segment := range s.tokens[coll] {
+		segments = append(segments, segment)
+	}
+	sort.Strings(segments)
+	return strings.Join(segments, ",")
+}
+
+// Parse a session token segment, e.g: "0:45" or "0:1#45#3=40", to its partition key range id
+// and global LSN
+func parseSessionSegment(segment string) (id string, lsn int64, ok bool) {
+	id, vector, ok := strings.Cut(strings.TrimSpace(segment), ":")
+	if !ok {
+		return "", 0, false
+	}
+	parts := strings.Split(vector, "#")
+	if len(parts) > 1 {
+		parts = parts[1:]
+	}
+	lsn, err := strconv.ParseInt(parts[0], 10, 64)
+	return id, lsn, err == nil
+}
+
+// Return the link of the collection a request path belongs to, e.g: "dbs/db/colls/coll"
+// for "/dbs/db/colls/coll/docs/1", empty for paths above collections
+func sessionCollection(path string) string {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	for i := 0; i+1 < len(parts); i += 2 {
+		if parts[i] == "colls" {
+			return strings.Join(parts[:i+2], "/")
+		}
+	}
+	return ""
+}
+
+// Send the tracked session token of the collection with the request, see Config.AutoTrackSessionToken
+func (c *Client) applySession(r *Request) {
+	if !c.Config.AutoTrackSessionToken {
+		return
+	}
+	if coll := sessionCollection(r.URL.Path); coll != "" {
+		if token := c.sessions.get(coll); token != "" {
+			r.Header.Set(HeaderSessionToken, token)
+		}
+	}
+}
+
+// Track the session token of a response, see Config.AutoTrackSessionToken
+func (c *Client) trackSession(r *Request, resp *http.Response) {
+	if !c.Config.AutoTrackSessionToken || resp == nil {
+		return
+	}
+	if token := resp.Header.Get(HeaderSessionToken); token != "" {
+		if coll := sessionCollection(r.URL.Path); coll != "" {
+			c.sessions.update(coll, token)
+		}
+	}
+}
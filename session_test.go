@@ -0,0 +1,84 @@
+package documentdb
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSessionTokens(t *testing.T) {
+	var s sessionTokens
+	assert.Equal(t, "", s.get("dbs/db/colls/coll"))
+	s.update("dbs/db/colls/coll", "0:1#45#3=40")
+	s.update("dbs/db/colls/coll", "1:10")
+	assert.Equal(t, "0:1#45#3=40,1:10", s.get("dbs/db/colls/coll"))
+	// An older token of a range doesn't replace the newer one
+	s.update("dbs/db/colls/coll", "0:1#44#3=40,1:12")
+	assert.Equal(t, "0:1#45#3=40,1:12", s.get("dbs/db/colls/coll"))
+	assert.Equal(t, "", s.get("dbs/db/colls/other"))
+
+	assert.Equal(t, "dbs/db/colls/coll", sessionCollection("/dbs/db/colls/coll/docs/1"))
+	assert.Equal(t, "dbs/db/colls/coll", sessionCollection("/dbs/db/colls/coll/"))
+	assert.Equal(t, "", sessionCollection("/dbs/db/"))
+}
+
+func TestAutoTrackSessionToken(t *testing.T) {
+	assert := assert.New(t)
+	var (
+		mu       sync.Mutex
+		received = map[string]string{}
+		lsn      = 0
+	)
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		received[r.URL.Path] = r.Header.Get(HeaderSessionToken)
+		if r.Method == http.MethodPost {
+			lsn++
+			w.Header().Set(HeaderSessionToken, fmt.Sprintf("0:%d", lsn))
+			w.WriteHeader(http.StatusCreated)
+		}
+		fmt.Fprint(w, `{}`)
+	}))
+	defer s.Close()
+	config := NewConfig(&Key{Key: "YXJpZWwNCg=="})
+	config.AutoTrackSessionToken = true
+	client := &Client{Url: s.URL, Config: config}
+
+	resp, err := client.Create("dbs/db/colls/coll/docs/", `{"id": "1"}`, nil)
+	assert.NoError(err)
+	assert.Equal("0:1", resp.SessionToken())
+	_, err = client.Read("dbs/db/colls/coll/docs/1", nil)
+	assert.NoError(err)
+	assert.Equal("0:1", received["/dbs/db/colls/coll/docs/1"])
+	// Other collections don't get the token
+	_, err = client.Read("dbs/db/colls/other/docs/1", nil)
+	assert.NoError(err)
+	assert.Equal("", received["/dbs/db/colls/other/docs/1"])
+	// The option overrides the tracked token
+	_, err = client.Read("dbs/db/colls/coll/docs/1", nil, SessionToken("0:0"))
+	assert.NoError(err)
+	assert.Equal("0:0", received["/dbs/db/colls/coll/docs/1"])
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			client.Create("dbs/db/colls/coll/docs/", `{"id": "1"}`, nil)
+			client.Read("dbs/db/colls/coll/docs/1", nil)
+		}()
+	}
+	wg.Wait()
+	assert.Equal("0:11", client.sessions.get("dbs/db/colls/coll"))
+
+	// Tokens aren't tracked by default
+	client = &Client{Url: s.URL, Config: NewConfig(&Key{Key: "YXJpZWwNCg=="})}
+	client.Create("dbs/db/colls/coll/docs/", `{"id": "1"}`, nil)
+	client.Read("dbs/db/colls/coll/docs/1", nil)
+	assert.Equal("", received["/dbs/db/colls/coll/docs/1"])
+}
@@ -0,0 +1,88 @@
+package documentdb
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// DefaultThrottleWindow is the period ThrottleRatio is computed over when Config.ThrottleWindow isn't set
+const DefaultThrottleWindow = time.Minute
+
+// The window is tracked with a ring of buckets, older buckets are dropped as time passes
+const throttleBuckets = 10
+
+type throttleBucket struct {
+	slot      int64
+	requests  int
+	throttled int
+}
+
+// Sliding window of the requests sent by a client, and how many of them were throttled
+type throttleWindow struct {
+	mu      sync.Mutex
+	buckets [throttleBuckets]throttleBucket
+}
+
+func (w *throttleWindow) record(width time.Duration, now time.Time, throttled bool) {
+	slot := now.UnixNano() / int64(width)
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	b := &w.buckets[slot%throttleBuckets]
+	if b.slot != slot {
+		*b = throttleBucket{slot: slot}
+	}
+	b.requests++
+	if throttled {
+		b.throttled++
+	}
+}
+
+func (w *throttleWindow) ratio(width time.Duration, now time.Time) float64 {
+	slot := now.UnixNano() / int64(width)
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	var requests, throttled int
+	for _, b := range w.buckets {
+		if b.slot > slot-throttleBuckets && b.slot <= slot {
+			requests += b.requests
+			throttled += b.throttled
+		}
+	}
+	if requests == 0 {
+		return 0
+	}
+	return float64(throttled) / float64(requests)
+}
+
+// The width of a bucket of the throttle window
+func (c *Client) throttleBucketWidth() time.Duration {
+	window := c.Config.ThrottleWindow
+	if window <= 0 {
+		window = DefaultThrottleWindow
+	}
+	return window / throttleBuckets
+}
+
+// ThrottleRatio returns the share(0-1) of the responses received over the last Config.ThrottleWindow
+// that were throttled(429). A ratio that stays high means the provisioned throughput can't keep up:
+// shed load at the application level rather than piling up retries.
+func (c *Client) ThrottleRatio() float64 {
+	return c.throttles.ratio(c.throttleBucketWidth(), time.Now())
+}
+
+// Record a response in the throttle window
+func (c *Client) observeThrottle(resp *http.Response) {
+	if resp != nil {
+		c.throttles.record(c.throttleBucketWidth(), time.Now(), resp.StatusCode == http.StatusTooManyRequests)
+	}
+}
+
+// ThrottleRatio returns the share(0-1) of the recent responses that were throttled, see Client.ThrottleRatio.
+// It's zero when the client isn't a *Client.
+func (c *DocumentDB) ThrottleRatio() float64 {
+	if client, ok := c.client.(*Client); ok {
+		return client.ThrottleRatio()
+	}
+	return 0
+}
@@ -0,0 +1,41 @@
+package documentdb
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestThrottleWindow(t *testing.T) {
+	var (
+		w     throttleWindow
+		width = time.Second
+		now   = time.Unix(1000, 0)
+	)
+	assert.Equal(t, 0.0, w.ratio(width, now))
+
+	w.record(width, now, true)
+	w.record(width, now, false)
+	w.record(width, now.Add(5*time.Second), false)
+	w.record(width, now.Add(5*time.Second), false)
+	assert.Equal(t, 0.25, w.ratio(width, now.Add(5*time.Second)))
+
+	// The first bucket is out of the window
+	assert.Equal(t, 0.0, w.ratio(width, now.Add(10*time.Second)))
+	w.record(width, now.Add(10*time.Second), true)
+	assert.Equal(t, 1.0/3, w.ratio(width, now.Add(10*time.Second)))
+}
+
+func TestThrottleRatio(t *testing.T) {
+	s, client, _ := RetryServerFactory(http.StatusTooManyRequests, http.StatusTooManyRequests, http.StatusTooManyRequests)
+	defer s.Close()
+	c := &DocumentDB{client, client.Config}
+
+	for i := 0; i < 4; i++ {
+		client.Read("dbs/db/colls/coll/docs/1", nil)
+	}
+	assert.Equal(t, 0.75, c.ThrottleRatio())
+	assert.Equal(t, 0.0, (&DocumentDB{client: &ClientStub{}}).ThrottleRatio())
+}
@@ -0,0 +1,89 @@
+package documentdb
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TimeFormat defines how Time values are written to documents, see Config.TimeFormat
+type TimeFormat int
+
+const (
+	// TimeFormatRFC3339 writes times as RFC3339 strings, e.g: "2006-01-02T15:04:05Z".
+	// Strings sort chronologically only if they share the same time zone.
+	TimeFormatRFC3339 TimeFormat = iota
+
+	// TimeFormatUnix writes times as the number of seconds since the Unix epoch, the format
+	// of "_ts" and the one expected for range queries against it. Sub-second times are written
+	// with a fraction, e.g: 1577934245.5, and zero times are written as null.
+	TimeFormatUnix
+)
+
+// Time is a time.Time written according to Config.TimeFormat by the client. Use it for the
+// timestamp fields of your models instead of time.Time. Both formats are accepted when reading,
+// so documents written before the format was changed can still be read. Marshaled on its own,
+// e.g: with json.Marshal, it's written as RFC3339.
+type Time struct {
+	time.Time
+}
+
+// MarshalJSON implements json.Marshaler
+func (t Time) MarshalJSON() ([]byte, error) {
+	return t.Time.MarshalJSON()
+}
+
+// The value of the time written in the given format
+func (t Time) value(format TimeFormat) interface{} {
+	if format != TimeFormatUnix {
+		return t
+	}
+	if t.IsZero() {
+		return nil
+	}
+	sec, nsec := t.Unix(), int64(t.Nanosecond())
+	if nsec == 0 {
+		return json.RawMessage(strconv.FormatInt(sec, 10))
+	}
+	sign := ""
+	if sec < 0 {
+		// Unix() rounds down, write the fraction towards zero
+		sign, sec, nsec = "-", -sec-1, int64(time.Second)-nsec
+	}
+	frac := strings.TrimRight(fmt.Sprintf("%09d", nsec), "0")
+	return json.RawMessage(sign + strconv.FormatInt(sec, 10) + "." + frac)
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (t *Time) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+	if len(data) > 0 && data[0] == '"' {
+		return t.Time.UnmarshalJSON(data)
+	}
+	s := string(data)
+	whole, frac, _ := strings.Cut(s, ".")
+	if sec, err := strconv.ParseInt(whole, 10, 64); err == nil && len(frac) <= 9 {
+		var nsec int64
+		if frac != "" {
+			if nsec, err = strconv.ParseInt(frac+strings.Repeat("0", 9-len(frac)), 10, 64); err != nil || nsec < 0 {
+				return fmt.Errorf("invalid unix time %s", s)
+			}
+			if strings.HasPrefix(whole, "-") {
+				nsec = -nsec
+			}
+		}
+		t.Time = time.Unix(sec, nsec).UTC()
+		return nil
+	}
+	sec, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return err
+	}
+	t.Time = time.Unix(0, int64(sec*float64(time.Second))).UTC()
+	return nil
+}
@@ -0,0 +1,100 @@
+package documentdb
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type timedDocument struct {
+	Document
+	Expires Time `json:"expires"`
+}
+
+func TestTimeEncoding(t *testing.T) {
+	assert := assert.New(t)
+	doc := timedDocument{Expires: Time{time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)}}
+
+	b, err := json.Marshal(doc)
+	assert.NoError(err)
+	assert.JSONEq(`{"expires": "2020-01-02T03:04:05Z"}`, string(b))
+
+	for format, expected := range map[TimeFormat]string{
+		TimeFormatRFC3339: `{"expires": "2020-01-02T03:04:05Z"}`,
+		TimeFormatUnix:    `{"expires": 1577934245}`,
+	} {
+		config := &Config{TimeFormat: format}
+		b, err = encodeDocument(config, doc)
+		assert.NoError(err)
+		assert.JSONEq(expected, string(b), "format %d", format)
+	}
+
+	// Clients don't share the format
+	b, err = encodeDocument(&Config{}, &doc)
+	assert.NoError(err)
+	assert.JSONEq(`{"expires": "2020-01-02T03:04:05Z"}`, string(b))
+}
+
+func TestTimeEncodingUnix(t *testing.T) {
+	assert := assert.New(t)
+	config := &Config{TimeFormat: TimeFormatUnix}
+	for value, expected := range map[time.Time]string{
+		time.Date(2020, 1, 2, 3, 4, 5, 500000000, time.UTC): `1577934245.5`,
+		time.Date(2020, 1, 2, 3, 4, 5, 123456789, time.UTC): `1577934245.123456789`,
+		time.Unix(-5, -250000000):                           `-5.25`,
+		time.Unix(0, -250000000):                            `-0.25`,
+		{}:                                                  `null`,
+	} {
+		b, err := encodeDocument(config, map[string]interface{}{"expires": Time{value}})
+		assert.NoError(err)
+		assert.JSONEq(`{"expires": `+expected+`}`, string(b))
+
+		var doc timedDocument
+		assert.NoError(json.Unmarshal(b, &doc))
+		assert.True(value.Equal(doc.Expires.Time), "%s should be read back as %v, got %v", expected, value, doc.Expires.Time)
+	}
+
+	// Zero times are omitted or written as null with the zero value modes
+	config.ZeroValues = ZeroValuesOmit
+	b, err := encodeDocument(config, timedDocument{})
+	assert.NoError(err)
+	assert.JSONEq(`{}`, string(b))
+	config.ZeroValues = ZeroValuesAsTagged
+	b, err = encodeDocument(config, &timedDocument{})
+	assert.NoError(err)
+	assert.JSONEq(`{"expires": null}`, string(b))
+}
+
+func TestTimeQueryParameters(t *testing.T) {
+	s := ServerFactory(`{"Documents": []}`)
+	defer s.Close()
+	config := NewConfig(&Key{Key: "YXJpZWwNCg=="})
+	config.TimeFormat = TimeFormatUnix
+	client := &Client{Url: s.URL, Config: config}
+
+	since := Time{time.Unix(1577934245, 0)}
+	query := NewQuery("SELECT * FROM c WHERE c._ts > @since").WithParam("@since", since)
+	_, err := client.Query("dbs/db/colls/coll/docs", query, &struct{}{})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"query": "SELECT * FROM c WHERE c._ts > @since", "parameters": [{"name": "@since", "value": 1577934245}]}`, s.Body)
+	assert.Equal(t, since, query.Parameters[0].Value, "should leave the caller query as is")
+}
+
+func TestTimeDecoding(t *testing.T) {
+	expected := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	for _, data := range []string{
+		`{"expires": "2020-01-02T03:04:05Z"}`,
+		`{"expires": 1577934245}`,
+	} {
+		var doc timedDocument
+		assert.NoError(t, json.Unmarshal([]byte(data), &doc), data)
+		assert.True(t, expected.Equal(doc.Expires.Time), data)
+	}
+
+	var doc timedDocument
+	assert.NoError(t, json.Unmarshal([]byte(`{"expires": null}`), &doc))
+	assert.True(t, doc.Expires.IsZero())
+	assert.Error(t, json.Unmarshal([]byte(`{"expires": true}`), &doc))
+}
@@ -0,0 +1,120 @@
+package documentdb
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// Tracer starts the spans of the calls of the client, see Config.Tracer. Implement it over your tracing
+// library, e.g: OpenTelemetry. Implementations must be safe for concurrent use.
+//
+// Every call gets a span named after its operation(e.g: "documentdb.Read"), with a child span per
+// http attempt("documentdb.attempt") and a "retry" event per retry, holding its reason and backoff.
+type Tracer interface {
+	// StartSpan starts a span as a child of the span of ctx, and returns the context holding it
+	StartSpan(ctx context.Context, name string) (context.Context, Span)
+}
+
+// Span is a span started by a Tracer
+type Span interface {
+	SetAttribute(key string, value interface{})
+	AddEvent(name string, attributes map[string]interface{})
+	// RecordError records the error and marks the span as failed
+	RecordError(err error)
+	End()
+}
+
+// The attributes of the spans, following the OpenTelemetry semantic conventions where they exist
+const (
+	AttributeDBSystem          = "db.system"
+	AttributeOperation         = "db.operation.name"
+	AttributeResourceLink      = "db.cosmosdb.resource_link"
+	AttributeRequestID         = "db.cosmosdb.client_request_id"
+	AttributeStatusCode        = "db.response.status_code"
+	AttributeSubStatusCode     = "db.cosmosdb.sub_status_code"
+	AttributeRequestCharge     = "db.cosmosdb.request_charge"
+	AttributeActivityID        = "db.cosmosdb.activity_id"
+	AttributeRetryCount        = "db.cosmosdb.retry_count"
+	AttributeRetryReason       = "db.cosmosdb.retry_reason"
+	AttributeRetryDelay        = "db.cosmosdb.retry_delay_ms"
+	AttributeAttempt           = "db.cosmosdb.attempt"
+	AttributeDiagnosticContext = "db.cosmosdb.context."
+)
+
+// A span discarding everything, of the calls sent without a tracer
+type nopSpan struct{}
+
+func (nopSpan) SetAttribute(string, interface{})        {}
+func (nopSpan) AddEvent(string, map[string]interface{}) {}
+func (nopSpan) RecordError(error)                       {}
+func (nopSpan) End()                                    {}
+
+// Start the span of a call, the span of its attempts are its children
+func (c *Client) startSpan(r *Request) Span {
+	tracer := c.Config.Tracer
+	if tracer == nil {
+		return nopSpan{}
+	}
+	ctx, span := tracer.StartSpan(r.Context(), "documentdb."+r.operation())
+	r.Request = r.WithContext(ctx)
+	r.span = span
+	span.SetAttribute(AttributeDBSystem, "cosmosdb")
+	span.SetAttribute(AttributeOperation, r.operation())
+	span.SetAttribute(AttributeResourceLink, r.URL.Path)
+	span.SetAttribute(AttributeRequestID, r.requestID())
+	for k, v := range r.diagnosticContext {
+		span.SetAttribute(AttributeDiagnosticContext+k, v)
+	}
+	return span
+}
+
+// End the span of a call
+func endSpan(span Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		if reqErr, ok := err.(*RequestError); ok {
+			span.SetAttribute(AttributeStatusCode, reqErr.StatusCode)
+			span.SetAttribute(AttributeSubStatusCode, reqErr.SubStatus)
+		}
+	}
+	span.End()
+}
+
+// Start the span of an attempt of the request, returning the context of the attempt
+func (c *Client) startAttemptSpan(r *Request, attempt int) (context.Context, Span) {
+	if c.Config.Tracer == nil || r.span == nil {
+		return r.Context(), nopSpan{}
+	}
+	ctx, span := c.Config.Tracer.StartSpan(r.Context(), "documentdb.attempt")
+	span.SetAttribute(AttributeAttempt, attempt)
+	return ctx, span
+}
+
+// End the span of an attempt with the outcome of its response
+func endAttemptSpan(span Span, resp *http.Response, err error) {
+	if resp != nil {
+		span.SetAttribute(AttributeStatusCode, resp.StatusCode)
+		if subStatus := resp.Header.Get(HeaderSubStatus); subStatus != "" {
+			span.SetAttribute(AttributeSubStatusCode, subStatus)
+		}
+		span.SetAttribute(AttributeRequestCharge, (&Response{Header: resp.Header}).RequestCharge())
+		span.SetAttribute(AttributeActivityID, resp.Header.Get(HeaderActivityID))
+	}
+	if err != nil {
+		span.RecordError(err)
+	}
+	span.End()
+}
+
+// Record a retry of the request on the span of the call
+func (r *Request) traceRetry(retry int, reason string, delay time.Duration) {
+	if r.span == nil {
+		return
+	}
+	r.span.SetAttribute(AttributeRetryCount, retry)
+	r.span.AddEvent("retry", map[string]interface{}{
+		AttributeRetryReason: reason,
+		AttributeRetryDelay:  delay.Milliseconds(),
+	})
+}
@@ -0,0 +1,84 @@
+package documentdb
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type SpanRecorder struct {
+	Name       string
+	Parent     *SpanRecorder
+	Attributes map[string]interface{}
+	Events     []string
+	Err        error
+	Ended      bool
+}
+
+func (s *SpanRecorder) SetAttribute(key string, value interface{}) { s.Attributes[key] = value }
+func (s *SpanRecorder) AddEvent(name string, attributes map[string]interface{}) {
+	s.Events = append(s.Events, name+":"+attributes[AttributeRetryReason].(string))
+}
+func (s *SpanRecorder) RecordError(err error) { s.Err = err }
+func (s *SpanRecorder) End()                  { s.Ended = true }
+
+type spanKey struct{}
+
+type TracerRecorder struct {
+	sync.Mutex
+	Spans []*SpanRecorder
+}
+
+func (t *TracerRecorder) StartSpan(ctx context.Context, name string) (context.Context, Span) {
+	t.Lock()
+	defer t.Unlock()
+	parent, _ := ctx.Value(spanKey{}).(*SpanRecorder)
+	span := &SpanRecorder{Name: name, Parent: parent, Attributes: map[string]interface{}{}}
+	t.Spans = append(t.Spans, span)
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+func TestTracer(t *testing.T) {
+	assert := assert.New(t)
+	s, client, _ := RetryServerFactory(http.StatusServiceUnavailable)
+	defer s.Close()
+	tracer := &TracerRecorder{}
+	client.Config.Tracer = tracer
+
+	_, err := client.Read("dbs/db/colls/coll/docs/1", nil, DiagnosticContext(map[string]string{"tenantId": "t1"}))
+	assert.NoError(err)
+	if assert.Len(tracer.Spans, 3) {
+		call, first, second := tracer.Spans[0], tracer.Spans[1], tracer.Spans[2]
+		assert.Equal("documentdb.Read", call.Name)
+		assert.Equal("Read", call.Attributes[AttributeOperation])
+		assert.Equal("/dbs/db/colls/coll/docs/1", call.Attributes[AttributeResourceLink])
+		assert.Equal("t1", call.Attributes[AttributeDiagnosticContext+"tenantId"])
+		assert.Equal(1, call.Attributes[AttributeRetryCount])
+		assert.Equal([]string{"retry:" + RetryReasonTransient}, call.Events)
+		assert.Nil(call.Err)
+		assert.True(call.Ended)
+
+		for i, attempt := range []*SpanRecorder{first, second} {
+			assert.Equal("documentdb.attempt", attempt.Name)
+			assert.Equal(call, attempt.Parent)
+			assert.Equal(i+1, attempt.Attributes[AttributeAttempt])
+			assert.True(attempt.Ended)
+		}
+		assert.Equal(http.StatusServiceUnavailable, first.Attributes[AttributeStatusCode])
+		assert.Equal(http.StatusOK, second.Attributes[AttributeStatusCode])
+	}
+
+	// A failed call marks its span as failed
+	tracer.Spans = nil
+	client.Config.RetryOptions.MaxRetries = 0
+	client.Url = "http://127.0.0.1:1"
+	_, err = client.Read("dbs/db/colls/coll/docs/1", nil)
+	assert.Error(err)
+	if assert.Len(tracer.Spans, 2) {
+		assert.Equal(err, tracer.Spans[0].Err)
+		assert.Error(tracer.Spans[1].Err)
+	}
+}
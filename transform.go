@@ -0,0 +1,48 @@
+package documentdb
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// DocumentTransform rewrites a raw document before it's decoded, see TransformDocuments
+type DocumentTransform func(raw json.RawMessage) (json.RawMessage, error)
+
+// TransformDocuments applies the transform to every document of a query or read feed page(e.g:
+// QueryDocuments, ReadDocuments or a DocumentIterator) before the page is decoded. Use it to
+// decrypt, rename or redact fields transparently instead of at every call site.
+// A failing transform fails the whole page.
+func TransformDocuments(transform DocumentTransform) CallOption {
+	return func(r *Request) error {
+		r.transform = transform
+		return nil
+	}
+}
+
+// Apply the transform to the documents of a feed page, other responses are returned as is
+func transformDocuments(page []byte, transform DocumentTransform) ([]byte, error) {
+	var envelope map[string]json.RawMessage
+	if err := Serialization.Unmarshal(page, &envelope); err != nil {
+		return page, nil
+	}
+	raw, ok := envelope["Documents"]
+	if !ok {
+		return page, nil
+	}
+	var docs []json.RawMessage
+	if err := Serialization.Unmarshal(raw, &docs); err != nil {
+		return nil, err
+	}
+	for i, doc := range docs {
+		transformed, err := transform(doc)
+		if err != nil {
+			return nil, fmt.Errorf("transform document %d of the page: %w", i, err)
+		}
+		docs[i] = transformed
+	}
+	var err error
+	if envelope["Documents"], err = Serialization.Marshal(docs); err != nil {
+		return nil, err
+	}
+	return Serialization.Marshal(envelope)
+}
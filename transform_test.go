@@ -0,0 +1,39 @@
+package documentdb
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransformDocuments(t *testing.T) {
+	assert := assert.New(t)
+	s := ServerFactory(`{"_rid": "r", "Documents": [{"id": "1", "secret": "a"}, {"id": "2", "secret": "b"}], "_count": 2}`,
+		`{"_rid": "r", "Documents": [{"id": "1"}, {"id": "fail"}], "_count": 2}`)
+	defer s.Close()
+	c := New(s.URL, NewConfig(&Key{Key: "YXJpZWwNCg=="}))
+
+	redact := TransformDocuments(func(raw json.RawMessage) (json.RawMessage, error) {
+		if bytes.Contains(raw, []byte(`"fail"`)) {
+			return nil, errors.New("can't decrypt")
+		}
+		var doc map[string]interface{}
+		json.Unmarshal(raw, &doc)
+		delete(doc, "secret")
+		doc["redacted"] = true
+		return json.Marshal(doc)
+	})
+	var docs []map[string]interface{}
+	resp, err := c.QueryDocuments("coll/", NewQuery("SELECT * FROM c"), &docs, redact)
+	assert.NoError(err)
+	assert.Equal([]map[string]interface{}{{"id": "1", "redacted": true}, {"id": "2", "redacted": true}}, docs)
+	assert.Equal(&FeedMetadata{Rid: "r", Count: 2}, resp.Feed)
+
+	docs = nil
+	_, err = c.QueryDocuments("coll/", NewQuery("SELECT * FROM c"), &docs, redact)
+	assert.EqualError(err, "transform document 1 of the page: can't decrypt")
+	assert.Empty(docs)
+}
@@ -0,0 +1,37 @@
+package documentdb
+
+import (
+	"net/http"
+	"time"
+)
+
+// ConnectionOptions tunes the connection pool of the http transport built by New, see Config.ConnectionOptions
+type ConnectionOptions struct {
+	// MaxIdleConns caps the idle connections across all hosts, zero means no limit
+	MaxIdleConns int
+	// MaxIdleConnsPerHost caps the idle connections kept per host. The net/http default of 2 makes
+	// a busy client open and close connections all the time against the single gateway host.
+	MaxIdleConnsPerHost int
+	// MaxConnsPerHost caps the connections per host, in any state. Zero means no limit.
+	MaxConnsPerHost int
+	// IdleConnTimeout is how long an idle connection is kept, zero means no limit
+	IdleConnTimeout time.Duration
+}
+
+// DefaultConnectionOptions holds the connection options set by NewConfig, following the gateway mode
+// guidance of the official SDKs
+var DefaultConnectionOptions = ConnectionOptions{
+	MaxIdleConns:        100,
+	MaxIdleConnsPerHost: 100,
+	IdleConnTimeout:     90 * time.Second,
+}
+
+// Build a transport with the given connection options, over the defaults of http.DefaultTransport
+func newTransport(o ConnectionOptions) *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConns = o.MaxIdleConns
+	transport.MaxIdleConnsPerHost = o.MaxIdleConnsPerHost
+	transport.MaxConnsPerHost = o.MaxConnsPerHost
+	transport.IdleConnTimeout = o.IdleConnTimeout
+	return transport
+}
@@ -0,0 +1,31 @@
+package documentdb
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConnectionOptions(t *testing.T) {
+	assert := assert.New(t)
+	config := NewConfig(&Key{Key: "YXJpZWwNCg=="})
+	config.ConnectionOptions.MaxConnsPerHost = 50
+	transport, ok := New("http://localhost", config).client.(*Client).Transport.(*http.Transport)
+	if assert.True(ok, "should build a transport") {
+		assert.Equal(100, transport.MaxIdleConns)
+		assert.Equal(100, transport.MaxIdleConnsPerHost)
+		assert.Equal(50, transport.MaxConnsPerHost)
+		assert.Equal(90*time.Second, transport.IdleConnTimeout)
+		assert.NotNil(transport.Proxy, "should keep the defaults of http.DefaultTransport")
+	}
+
+	// A transport of the caller is kept
+	custom := &http.Transport{}
+	config.Client.Transport = custom
+	assert.Equal(custom, New("http://localhost", config).client.(*Client).Transport)
+
+	config = &Config{MasterKey: &Key{Key: "YXJpZWwNCg=="}}
+	assert.Nil(New("http://localhost", config).client.(*Client).Transport, "zero options should keep http.DefaultTransport")
+}
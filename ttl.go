@@ -0,0 +1,95 @@
+package documentdb
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ErrExpired matches the not found errors ReadUnexpiredDocument reports for expired documents,
+// check it with errors.Is to tell them apart from documents that are actually deleted
+var ErrExpired = errors.New("document expired")
+
+// ExpiresAt returns the time a document expires at, given its last modification time(_ts), its own
+// "ttl" and the default TTL of its collection. The bool result is false for documents that never expire.
+func ExpiresAt(ts int64, ttl, defaultTTL *int) (time.Time, bool) {
+	if defaultTTL == nil {
+		// Expiry is disabled on the collection, "ttl" is ignored
+		return time.Time{}, false
+	}
+	if ttl == nil {
+		ttl = defaultTTL
+	}
+	if *ttl <= 0 {
+		return time.Time{}, false
+	}
+	return time.Unix(ts+int64(*ttl), 0), true
+}
+
+// ReadUnexpiredDocument reads a document by self link like ReadDocument, but fails with a
+// 404 error matching ErrExpired if the document outlived its TTL.
+//
+// The server purges expired documents in the background, and may still return them for a
+// while after they expired. This check makes expiry deterministic on the client side, on a
+// best-effort basis: it relies on _ts(a one second resolution) and the local clock.
+// Documents that don't set their own "ttl" cost an extra read of the collection, for its default TTL.
+func (c *DocumentDB) ReadUnexpiredDocument(link string, doc interface{}, opts ...CallOption) (*Response, error) {
+	var raw json.RawMessage
+	resp, err := c.client.Read(link, &raw, opts...)
+	if err != nil {
+		return nil, err
+	}
+	var meta map[string]json.RawMessage
+	if err = Serialization.Unmarshal(raw, &meta); err != nil {
+		return nil, err
+	}
+	var (
+		ts  int64
+		ttl *int
+	)
+	if err = unmarshalProperty(meta, c.propertyName("_ts"), &ts); err != nil {
+		return nil, err
+	}
+	if err = unmarshalProperty(meta, "ttl", &ttl); err != nil {
+		return nil, err
+	}
+	var defaultTTL *int
+	if ttl == nil || *ttl > 0 {
+		coll, err := c.ReadCollection(collectionLink(link))
+		if err != nil {
+			return nil, err
+		}
+		defaultTTL = coll.DefaultTTL
+	}
+	if expiresAt, ok := ExpiresAt(ts, ttl, defaultTTL); ok && !time.Now().Before(expiresAt) {
+		return nil, &RequestError{Code: "NotFound", Message: "Resource expired", StatusCode: http.StatusNotFound, expired: true}
+	}
+	return resp, Serialization.Unmarshal(raw, doc)
+}
+
+// Return the json name of a system property, see Config.SystemPropertyNames
+func (c *DocumentDB) propertyName(name string) string {
+	if c.config != nil {
+		if renamed, ok := c.config.SystemPropertyNames[name]; ok {
+			return renamed
+		}
+	}
+	return name
+}
+
+func unmarshalProperty(obj map[string]json.RawMessage, name string, v interface{}) error {
+	if data, ok := obj[name]; ok {
+		return Serialization.Unmarshal(data, v)
+	}
+	return nil
+}
+
+// Return the self link of the collection a document belongs to
+func collectionLink(doc string) string {
+	if i := strings.LastIndex(doc, "/docs/"); i != -1 {
+		return doc[:i+1]
+	}
+	return doc
+}
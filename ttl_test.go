@@ -0,0 +1,76 @@
+package documentdb
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestExpiresAt(t *testing.T) {
+	ttl := func(n int) *int { return &n }
+	for _, test := range []struct {
+		ttl, defaultTTL *int
+		expires         bool
+		at              int64
+	}{
+		{nil, nil, false, 0},
+		{ttl(10), nil, false, 0},
+		{nil, ttl(-1), false, 0},
+		{nil, ttl(60), true, 160},
+		{ttl(10), ttl(60), true, 110},
+		{ttl(-1), ttl(60), false, 0},
+		{ttl(10), ttl(-1), true, 110},
+	} {
+		at, ok := ExpiresAt(100, test.ttl, test.defaultTTL)
+		assert.Equal(t, test.expires, ok)
+		if ok {
+			assert.Equal(t, test.at, at.Unix())
+		}
+	}
+}
+
+func TestReadUnexpiredDocument(t *testing.T) {
+	assert := assert.New(t)
+	client := &ClientStub{}
+	c := &DocumentDB{client, NewConfig(&Key{Key: "YXJpZWwNCg=="})}
+	now := time.Now().Unix()
+	for link, doc := range map[string]string{
+		"dbs/db/colls/coll/docs/live/":    fmt.Sprintf(`{"id": "live", "_ts": %d}`, now),
+		"dbs/db/colls/coll/docs/expired/": fmt.Sprintf(`{"id": "expired", "_ts": %d}`, now-120),
+		"dbs/db/colls/coll/docs/own/":     fmt.Sprintf(`{"id": "own", "_ts": %d, "ttl": 600}`, now-120),
+		"dbs/db/colls/coll/docs/never/":   fmt.Sprintf(`{"id": "never", "_ts": %d, "ttl": -1}`, now-120),
+	} {
+		doc := doc
+		client.On("Read", link, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			*args.Get(1).(*json.RawMessage) = json.RawMessage(doc)
+		}).Return(&Response{}, nil)
+	}
+	client.On("Read", "dbs/db/colls/coll/", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		json.Unmarshal([]byte(`{"id": "coll", "defaultTtl": 60}`), args.Get(1))
+	}).Return(&Response{}, nil)
+
+	var doc Document
+	_, err := c.ReadUnexpiredDocument("dbs/db/colls/coll/docs/live/", &doc)
+	assert.NoError(err)
+	assert.Equal("live", doc.Id)
+
+	_, err = c.ReadUnexpiredDocument("dbs/db/colls/coll/docs/expired/", &doc)
+	assert.True(errors.Is(err, ErrExpired))
+	if reqErr, ok := err.(*RequestError); assert.True(ok) {
+		assert.Equal(http.StatusNotFound, reqErr.StatusCode)
+	}
+
+	_, err = c.ReadUnexpiredDocument("dbs/db/colls/coll/docs/own/", &doc)
+	assert.NoError(err)
+	assert.Equal("own", doc.Id)
+
+	_, err = c.ReadUnexpiredDocument("dbs/db/colls/coll/docs/never/", &doc)
+	assert.NoError(err)
+	client.AssertNumberOfCalls(t, "Read", 7)
+}
@@ -0,0 +1,29 @@
+package documentdb
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// CreateTyped creates the document and returns the stored version decoded as T, populated
+// with the server generated fields(e.g: "_etag", "_rid", "_self", "_ts").
+// Unlike CreateDocument, doc is left untouched. T is the document type itself, not a pointer
+// to it, e.g: CreateTyped(db, coll, user) rather than CreateTyped(db, coll, &user).
+func CreateTyped[T any](c *DocumentDB, coll string, doc T, opts ...CallOption) (*T, *Response, error) {
+	switch t := reflect.TypeOf(doc); {
+	case t == nil:
+		return nil, nil, errors.New("document is nil")
+	case t.Kind() == reflect.Ptr:
+		return nil, nil, fmt.Errorf("document type %v must not be a pointer", t)
+	}
+	if c.config != nil && c.config.IdentificationHydrator != nil {
+		c.config.IdentificationHydrator(c.config, &doc)
+	}
+	created := new(T)
+	r, err := c.client.Create(coll+"docs/", doc, created, opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+	return created, r, nil
+}
@@ -0,0 +1,64 @@
+package documentdb
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type typedUser struct {
+	Document
+	Name string `json:"name"`
+}
+
+func TestCreateTyped(t *testing.T) {
+	s, c := BulkServerFactory(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, `{"_etag": "etag", "_rid": "rid", "_self": "self", "_ts": 10, %s`, b[1:])
+	})
+	defer s.Close()
+
+	user := typedUser{Name: "john"}
+	created, r, err := CreateTyped(c, "coll/", user)
+	assert.NoError(t, err)
+	assert.NotNil(t, r)
+	assert.Equal(t, "john", created.Name)
+	assert.NotEmpty(t, created.Id, "should hydrate the id")
+	assert.Equal(t, Resource{Id: created.Id, Etag: "etag", Rid: "rid", Self: "self", Ts: 10}, created.Resource)
+	assert.Empty(t, user.Id, "should leave the given document untouched")
+}
+
+func TestCreateTypedFailure(t *testing.T) {
+	s, c := BulkServerFactory(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"code": "Conflict", "message": "Resource with specified id already exists."}`, http.StatusConflict)
+	})
+	defer s.Close()
+
+	created, r, err := CreateTyped(c, "coll/", typedUser{})
+	assert.Nil(t, created)
+	assert.Nil(t, r)
+	assert.Equal(t, http.StatusConflict, err.(*RequestError).StatusCode)
+}
+
+func TestCreateTypedPointer(t *testing.T) {
+	calls := 0
+	s, c := BulkServerFactory(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+	})
+	defer s.Close()
+
+	user := &typedUser{Name: "john"}
+	created, r, err := CreateTyped(c, "coll/", user)
+	assert.EqualError(t, err, "document type *documentdb.typedUser must not be a pointer")
+	assert.Nil(t, created)
+	assert.Nil(t, r)
+	assert.Empty(t, user.Id)
+	assert.Equal(t, 0, calls)
+
+	_, _, err = CreateTyped[interface{}](c, "coll/", nil)
+	assert.EqualError(t, err, "document is nil")
+}
@@ -0,0 +1,58 @@
+package documentdb
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ErrMultipleDocuments is returned by ReadDocumentByUniqueKey when more than one document matches
+// the key, e.g: for a key that is only unique within each logical partition, queried across partitions
+var ErrMultipleDocuments = errors.New("multiple documents match the unique key")
+
+// ReadDocumentByUniqueKey reads the single document of a collection whose unique key path(e.g: "/email")
+// equals value. It fails with a 404 error, like ReadDocument, if no document matches, and with
+// ErrMultipleDocuments if more than one does.
+//
+// There is no point read by unique key, so the document is looked up with a parameterized query:
+// it costs more request units than a point read by id(1 RU for a 1KB document), at least a few RUs
+// depending on the index lookup, and more when it runs across partitions.
+// Unique keys are enforced within each logical partition, pass the PartitionKey of the document
+// to keep the query on a single partition, or CrossPartition to search the whole collection.
+func (c *DocumentDB) ReadDocumentByUniqueKey(coll, path string, value interface{}, doc interface{}, opts ...CallOption) (*Response, error) {
+	query := NewQuery(fmt.Sprintf("SELECT TOP 2 * FROM root r WHERE r%s = @value", propertyPath(path)), P{"@value", value})
+	var (
+		matches  []json.RawMessage
+		response *Response
+		err      error
+	)
+	// Cross partition queries may return empty pages before the matching ones
+	for continuation := ""; ; {
+		var page []json.RawMessage
+		response, err = c.QueryDocuments(coll, query, &page, append(opts[:len(opts):len(opts)], Continuation(continuation))...)
+		if err != nil {
+			return nil, err
+		}
+		if matches = append(matches, page...); len(matches) > 1 {
+			return nil, fmt.Errorf("read by unique key %s: %w", path, ErrMultipleDocuments)
+		}
+		if continuation = response.Continuation(); continuation == "" {
+			break
+		}
+	}
+	if len(matches) == 0 {
+		return nil, &RequestError{Code: "NotFound", Message: "Resource Not Found", StatusCode: http.StatusNotFound}
+	}
+	return response, Serialization.Unmarshal(matches[0], doc)
+}
+
+// Return the SQL property accessor of a document path, e.g: ["address"]["zip"] for "/address/zip"
+func propertyPath(path string) string {
+	var b strings.Builder
+	for _, name := range strings.Split(strings.Trim(path, "/"), "/") {
+		fmt.Fprintf(&b, "[%q]", name)
+	}
+	return b.String()
+}
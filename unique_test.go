@@ -0,0 +1,62 @@
+package documentdb
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadDocumentByUniqueKey(t *testing.T) {
+	assert := assert.New(t)
+	pages := map[string]string{
+		"one":      `{"Documents": [{"id": "1", "email": "one"}]}`,
+		"none":     `{"Documents": []}`,
+		"multiple": `{"Documents": [{"id": "1"}, {"id": "2"}]}`,
+	}
+	s, c := BulkServerFactory(func(w http.ResponseWriter, r *http.Request) {
+		var q Query
+		json.NewDecoder(r.Body).Decode(&q)
+		assert.Equal(`SELECT TOP 2 * FROM root r WHERE r["user"]["email"] = @value`, q.Query)
+		fmt.Fprint(w, pages[q.Parameters[0].Value.(string)])
+	})
+	defer s.Close()
+
+	var doc Document
+	_, err := c.ReadDocumentByUniqueKey("coll/", "/user/email", "one", &doc)
+	assert.NoError(err)
+	assert.Equal("1", doc.Id)
+
+	_, err = c.ReadDocumentByUniqueKey("coll/", "/user/email", "none", &doc)
+	var reqErr *RequestError
+	assert.True(errors.As(err, &reqErr))
+	assert.Equal(http.StatusNotFound, reqErr.StatusCode)
+
+	_, err = c.ReadDocumentByUniqueKey("coll/", "/user/email", "multiple", &doc)
+	assert.True(errors.Is(err, ErrMultipleDocuments))
+}
+
+func TestReadDocumentByUniqueKeyPages(t *testing.T) {
+	assert := assert.New(t)
+	calls := 0
+	// An empty page of the first partition, then the match on the second one
+	s, c := BulkServerFactory(func(w http.ResponseWriter, r *http.Request) {
+		if calls++; calls == 1 {
+			w.Header().Set(HeaderContinuation, "next")
+			fmt.Fprint(w, `{"Documents": []}`)
+			return
+		}
+		assert.Equal("next", r.Header.Get(HeaderContinuation))
+		fmt.Fprint(w, `{"Documents": [{"id": "1"}]}`)
+	})
+	defer s.Close()
+
+	var doc Document
+	_, err := c.ReadDocumentByUniqueKey("coll/", "/email", "one", &doc, CrossPartition())
+	assert.NoError(err)
+	assert.Equal("1", doc.Id)
+	assert.Equal(2, calls)
+}
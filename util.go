@@ -1,8 +1,10 @@
 package documentdb
 
 import (
+	"context"
 	"crypto/rand"
 	"fmt"
+	"time"
 )
 
 // generates a random UUID according to RFC 4122
@@ -18,3 +20,23 @@ func uuid() string {
 	uuid[6] = uuid[6]&^0xf0 | 0x40
 	return fmt.Sprintf("%x-%x-%x-%x-%x", uuid[0:4], uuid[4:6], uuid[6:8], uuid[8:10], uuid[10:])
 }
+
+// sleep pauses the current goroutine for at least d, returning early with the
+// context error if ctx is done before the delay is over
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// exceedsDeadline reports whether waiting d would run past the context deadline,
+// in which case there's no point in starting another attempt
+func exceedsDeadline(ctx context.Context, d time.Duration) bool {
+	deadline, ok := ctx.Deadline()
+	return ok && time.Now().Add(d).After(deadline)
+}
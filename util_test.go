@@ -0,0 +1,31 @@
+package documentdb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSleep(t *testing.T) {
+	start := time.Now()
+	assert.NoError(t, sleep(context.Background(), 10*time.Millisecond))
+	assert.True(t, time.Since(start) >= 10*time.Millisecond, "should sleep for the whole delay")
+}
+
+func TestSleepCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+	start := time.Now()
+	assert.Equal(t, context.Canceled, sleep(ctx, time.Minute))
+	assert.True(t, time.Since(start) < time.Second, "should return as soon as the context is cancelled")
+}
+
+func TestExceedsDeadline(t *testing.T) {
+	assert.False(t, exceedsDeadline(context.Background(), time.Hour))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	assert.False(t, exceedsDeadline(ctx, time.Second))
+	assert.True(t, exceedsDeadline(ctx, time.Hour))
+}
@@ -0,0 +1,192 @@
+package documentdb
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+// ZeroValues defines how zero-value struct fields(0, "", false, nil, empty slices and maps,
+// zero structs) are written to documents, see Config.ZeroValues
+type ZeroValues int
+
+const (
+	// ZeroValuesAsTagged follows the json tags: fields tagged with omitempty are omitted, others
+	// are written as their zero value. This is the default.
+	ZeroValuesAsTagged ZeroValues = iota
+
+	// ZeroValuesOmit omits every zero-value field. The properties are undefined in the stored
+	// document: IS_DEFINED(c.field) is false, and "c.field = null" doesn't match them.
+	// An omitted "ttl" falls back to the default TTL of the collection.
+	ZeroValuesOmit
+
+	// ZeroValuesNull writes every zero-value field as null. The properties are defined:
+	// IS_DEFINED(c.field) is true and "c.field = null" matches them. Note that "ttl" must be
+	// a positive number or -1 when present, keep it out of this mode with a pointer field.
+	ZeroValuesNull
+
+	// ZeroValuesKeep writes every field as its value, ignoring omitempty. Zero numbers, empty
+	// strings and false are regular values in queries, e.g: "c.count = 0" matches them.
+	ZeroValuesKeep
+)
+
+var (
+	marshalerType     = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	timeType          = reflect.TypeOf(Time{})
+)
+
+// How the values of written documents are serialized, see Config.ZeroValues and Config.TimeFormat
+type valueEncoding struct {
+	zeroValues ZeroValues
+	timeFormat TimeFormat
+}
+
+// Convert a document to a generic value, writing its zero-value fields and its Time values according
+// to the encoding. Values that aren't structs(e.g: raw json) are returned as is, and so are maps and
+// slices unless the times are written as Unix timestamps.
+func applyValueEncoding(doc interface{}, enc valueEncoding) interface{} {
+	if enc == (valueEncoding{}) {
+		return doc
+	}
+	v := reflect.ValueOf(doc)
+	for v.Kind() == reflect.Ptr && !v.IsNil() {
+		v = v.Elem()
+	}
+	switch {
+	case v.Kind() == reflect.Struct && v.Type() == timeType:
+		return v.Interface().(Time).value(enc.timeFormat)
+	case v.Kind() == reflect.Struct && !isMarshaler(v.Type()):
+	case (v.Kind() == reflect.Map || v.Kind() == reflect.Slice) && enc.timeFormat == TimeFormatUnix:
+	default:
+		return doc
+	}
+	return zeroValues(v, enc)
+}
+
+func zeroValues(v reflect.Value, enc valueEncoding) interface{} {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		// Interfaces are encoded by their dynamic value
+		if v.Kind() == reflect.Ptr && isMarshaler(v.Type()) && v.Type().Elem() != timeType {
+			return v.Interface()
+		}
+		return zeroValues(v.Elem(), enc)
+	case reflect.Struct:
+		if v.Type() == timeType {
+			return v.Interface().(Time).value(enc.timeFormat)
+		}
+		if isMarshaler(v.Type()) {
+			// Keep pointer receiver marshalers working
+			if v.CanAddr() {
+				return v.Addr().Interface()
+			}
+			return v.Interface()
+		}
+		obj := make(map[string]interface{})
+		zeroValuesFields(v, enc, obj)
+		return obj
+	case reflect.Slice:
+		if v.IsNil() || isMarshaler(v.Type()) || v.Type().Elem().Kind() == reflect.Uint8 {
+			return v.Interface()
+		}
+		fallthrough
+	case reflect.Array:
+		arr := make([]interface{}, v.Len())
+		for i := range arr {
+			arr[i] = zeroValues(v.Index(i), enc)
+		}
+		return arr
+	case reflect.Map:
+		if v.IsNil() || isMarshaler(v.Type()) || v.Type().Key().Kind() != reflect.String {
+			return v.Interface()
+		}
+		obj := make(map[string]interface{}, v.Len())
+		for _, k := range v.MapKeys() {
+			obj[k.String()] = zeroValues(v.MapIndex(k), enc)
+		}
+		return obj
+	}
+	return v.Interface()
+}
+
+// Set the struct fields in obj by their json names, embedded structs are flattened
+func zeroValuesFields(v reflect.Value, enc valueEncoding, obj map[string]interface{}) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field, value := t.Field(i), v.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" || field.PkgPath != "" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" {
+			if value.Kind() == reflect.Ptr {
+				if value.IsNil() {
+					continue
+				}
+				value = value.Elem()
+			}
+			if value.Kind() == reflect.Struct && !isMarshaler(value.Type()) {
+				zeroValuesFields(value, enc, obj)
+				continue
+			}
+		}
+		if name == "" {
+			name = field.Name
+		}
+		switch mode := enc.zeroValues; {
+		case mode == ZeroValuesAsTagged:
+			// Follow the json tags, like encoding/json does
+			if !strings.Contains(","+options+",", ",omitempty,") || !isOmitEmptyValue(value) {
+				obj[name] = zeroValues(value, enc)
+			}
+		case !isEmptyValue(value):
+			obj[name] = zeroValues(value, enc)
+		case isSystemProperty(name):
+			// Unset system properties are left for the server to fill
+		case mode == ZeroValuesNull:
+			obj[name] = nil
+		case mode == ZeroValuesKeep:
+			obj[name] = zeroValues(value, enc)
+		}
+	}
+}
+
+// Check if the value is empty the way omitempty defines it, or is a zero struct
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	}
+	return v.IsZero()
+}
+
+// Check if the value is empty the way omitempty defines it, structs are never empty
+func isOmitEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Struct:
+		return false
+	case reflect.Array:
+		return v.Len() == 0
+	}
+	return isEmptyValue(v)
+}
+
+func isSystemProperty(name string) bool {
+	for _, p := range SystemProperties {
+		if p == name {
+			return true
+		}
+	}
+	return false
+}
+
+func isMarshaler(t reflect.Type) bool {
+	return t.Implements(marshalerType) || t.Implements(textMarshalerType) ||
+		reflect.PtrTo(t).Implements(marshalerType) || reflect.PtrTo(t).Implements(textMarshalerType)
+}
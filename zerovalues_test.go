@@ -0,0 +1,87 @@
+package documentdb
+
+import (
+	"encoding"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type zeroValuesDoc struct {
+	Document
+	Name     string             `json:"name,omitempty"`
+	Count    int                `json:"count"`
+	Active   bool               `json:"active,omitempty"`
+	Tags     []string           `json:"tags"`
+	Expires  Time               `json:"expires"`
+	Nested   *zeroValuesNested  `json:"nested,omitempty"`
+	Children []zeroValuesNested `json:"children"`
+	Ignored  string             `json:"-"`
+	internal string
+}
+
+type zeroValuesNested struct {
+	Score float64 `json:"score"`
+	Label string  `json:"label,omitempty"`
+}
+
+func TestZeroValues(t *testing.T) {
+	doc := &zeroValuesDoc{
+		Document: Document{Resource: Resource{Id: "1"}},
+		Children: []zeroValuesNested{{Label: "a"}},
+		Ignored:  "ignored",
+		internal: "internal",
+	}
+	for mode, expected := range map[ZeroValues]string{
+		ZeroValuesAsTagged: `{"id": "1", "count": 0, "tags": null, "expires": "0001-01-01T00:00:00Z", "children": [{"score": 0, "label": "a"}]}`,
+		ZeroValuesOmit:     `{"id": "1", "children": [{"label": "a"}]}`,
+		ZeroValuesNull: `{"id": "1",
+			"name": null, "count": null, "active": null, "tags": null, "expires": null, "nested": null,
+			"children": [{"score": null, "label": "a"}]}`,
+		ZeroValuesKeep: `{"id": "1",
+			"name": "", "count": 0, "active": false, "tags": null, "expires": "0001-01-01T00:00:00Z", "nested": null,
+			"children": [{"score": 0, "label": "a"}]}`,
+	} {
+		data, err := stringify(applyValueEncoding(doc, valueEncoding{zeroValues: mode}))
+		assert.NoError(t, err)
+		assert.JSONEq(t, expected, string(data), "mode %d", mode)
+	}
+}
+
+func TestZeroValuesNonStruct(t *testing.T) {
+	for _, doc := range []interface{}{`{"id": ""}`, []byte(`{}`), map[string]interface{}{"id": ""}, Time{time.Now()}} {
+		assert.Equal(t, doc, applyValueEncoding(doc, valueEncoding{zeroValues: ZeroValuesOmit}))
+	}
+}
+
+func TestZeroValuesMarshalerInterface(t *testing.T) {
+	doc := struct {
+		Raw     json.Marshaler         `json:"raw"`
+		Text    encoding.TextMarshaler `json:"text"`
+		Expires json.Marshaler         `json:"expires"`
+		Missing json.Marshaler         `json:"missing,omitempty"`
+	}{
+		Raw:     json.RawMessage(`{"a":1}`),
+		Text:    time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+		Expires: Time{time.Unix(1577934245, 0)},
+	}
+	data, err := stringify(applyValueEncoding(doc, valueEncoding{zeroValues: ZeroValuesOmit, timeFormat: TimeFormatUnix}))
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"raw": {"a": 1}, "text": "2020-01-02T03:04:05Z", "expires": 1577934245}`, string(data))
+}
+
+func TestClientZeroValues(t *testing.T) {
+	s := ServerFactory(`{}`)
+	s.SetStatus(http.StatusCreated)
+	defer s.Close()
+	config := NewConfig(&Key{Key: "YXJpZWwNCg=="})
+	config.ZeroValues = ZeroValuesOmit
+	client := &Client{Url: s.URL, Config: config}
+
+	_, err := client.Create("dbs/db/colls/coll/docs/", &zeroValuesDoc{Document: Document{Resource: Resource{Id: "1"}}}, nil)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"id": "1"}`, s.Body)
+}